@@ -12,12 +12,21 @@ package constellation
 // or
 //    dcPointer := constellation.LoadString(<yamlTextString>)
 //
-// Parsing failures are indicated by a nil return.
+// Parsing failures are indicated by a *ParseError.
 ////////////////////////////////////////////////////////////
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
+	"os"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/microsoft/abstrakt/tools/guid"
 	"gopkg.in/dealancer/validate.v2"
@@ -27,7 +36,7 @@ import (
 // Note: the yaml mappings are necessary (despite the 1-1 name correspondence).
 // The yaml parser would otherwise expect the names in the YAML file to be all
 // lower-case.  e.g. ChartName would only work if "chartname" was used in the
-// yaml file.
+// yaml file.  The json mappings keep JSON in step with the yaml names.
 
 // Property - an individual property in the DAG.
 // For now, these are just interfaces as the value types are not firmed up
@@ -39,45 +48,280 @@ type Property interface{}
 
 // Service -- a DAG Service description
 type Service struct {
-	ID         string              `yaml:"Id" validate:"empty=false"`
-	Type       string              `yaml:"Type" validate:"empty=false"`
-	Properties map[string]Property `yaml:"Properties"`
+	ID         string              `yaml:"Id" json:"Id" validate:"empty=false"`
+	Type       string              `yaml:"Type" json:"Type" validate:"empty=false"`
+	Properties map[string]Property `yaml:"Properties" json:"Properties"`
 }
 
 // Relationship -- a relationship between Services
 type Relationship struct {
-	ID          string              `yaml:"Id" validate:"empty=false"`
-	Description string              `yaml:"Description"`
-	From        string              `yaml:"From" validate:"empty=false"`
-	To          string              `yaml:"To" validate:"empty=false"`
-	Properties  map[string]Property `yaml:"Properties"`
+	ID          string              `yaml:"Id" json:"Id" validate:"empty=false"`
+	Description string              `yaml:"Description" json:"Description"`
+	From        string              `yaml:"From" json:"From" validate:"empty=false"`
+	To          string              `yaml:"To" json:"To" validate:"empty=false"`
+	Properties  map[string]Property `yaml:"Properties" json:"Properties"`
 }
 
 // Config -- The DAG config for a deployment
+// Not safe for concurrent mutation, see SafeConfig.
 type Config struct {
-	Name          string         `yaml:"Name" validate:"empty=false"`
-	ID            guid.GUID      `yaml:"Id" validate:"empty=false"`
-	Services      []Service      `yaml:"Services" validate:"empty=false"`
-	Relationships []Relationship `yaml:"Relationships"`
+	Name          string         `yaml:"Name" json:"Name" validate:"empty=false"`
+	ID            guid.GUID      `yaml:"Id" json:"Id" validate:"empty=false"`
+	Services      []Service      `yaml:"Services" json:"Services" validate:"empty=false"`
+	Relationships []Relationship `yaml:"Relationships" json:"Relationships"`
+
+	// CaseMatching -- whether the Find methods tolerate IDs which differ only in case, never serialized.
+	// The zero value follows guid.TolerateMiscasedKey, see CaseInsensitive.
+	CaseMatching CaseMatching `yaml:"-" json:"-"`
+
+	index *index
+}
+
+// CaseMatching -- how the Find methods of a Config compare IDs.
+type CaseMatching int
+
+const (
+	// DefaultCase -- match as guid.TolerateMiscasedKey says.
+	DefaultCase CaseMatching = iota
+	// ExactCase -- match IDs exactly.
+	ExactCase
+	// AnyCase -- also match IDs which differ only in case.
+	AnyCase
+)
+
+// CaseInsensitive -- true if the Find methods tolerate IDs which differ only in case.
+func (m *Config) CaseInsensitive() bool {
+	if m.CaseMatching == DefaultCase {
+		return guid.TolerateMiscasedKey
+	}
+	return m.CaseMatching == AnyCase
+}
+
+// NewConfigFromEdges -- New DAG info instance from a list of From/To Service pairs.
+// Every distinct endpoint becomes a Service of Type "Generated", endpoints differing in case being distinct
+// Services, and every pair a Relationship. The DAG and its Relationships are given fresh GUIDs.
+func NewConfigFromEdges(name string, edges [][2]string) (*Config, error) {
+	m := &Config{Name: name, ID: guid.New()}
+	seen := make(map[string]bool)
+
+	for _, i := range edges {
+		for _, j := range i {
+			if j == "" {
+				return nil, fmt.Errorf("Relationship %v -> %v has an empty endpoint", i[0], i[1])
+			}
+			if !seen[j] {
+				seen[j] = true
+				m.Services = append(m.Services, Service{ID: j, Type: "Generated", Properties: make(map[string]Property)})
+			}
+		}
+
+		m.Relationships = append(m.Relationships, Relationship{
+			ID:          string(guid.New()),
+			Description: fmt.Sprintf("%v to %v", i[0], i[1]),
+			From:        i[0],
+			To:          i[1],
+			Properties:  make(map[string]Property),
+		})
+	}
+
+	return m, nil
+}
+
+// NewConfigFromCSV -- New DAG info instance from CSV rows of From Service, To Service and Relationship
+// Description. Services are created once per distinct name, the DAG and its Relationships are given fresh GUIDs.
+func NewConfigFromCSV(name string, r io.Reader) (*Config, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if nil != err {
+		return nil, err
+	}
+
+	edges := make([][2]string, len(rows))
+	for i, row := range rows {
+		edges[i] = [2]string{row[0], row[1]}
+	}
+
+	m, err := NewConfigFromEdges(name, edges)
+	if nil != err {
+		return nil, err
+	}
+
+	for i, row := range rows {
+		m.Relationships[i].Description = row[2]
+	}
+
+	return m, nil
+}
+
+// ParseError -- the yaml of a constellation couldn't be parsed. The message is the parser's own, the
+// fields locate the failure. A file which can't be read is reported with the underlying os error instead.
+type ParseError struct {
+	FileName string // empty when loaded from a string
+	Line     int    // the first line reported by the parser, 0 if unknown
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap -- the underlying yaml error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// yamlLine matches the line number in yaml syntax and type errors, e.g. "yaml: line 26: ...".
+var yamlLine = regexp.MustCompile(`line (\d+):`)
+
+// NewRandomConfig -- New DAG info instance with the given number of Services, connecting each pair of them with
+// probability edgeProb. Relationships only run from a lower to a higher numbered Service, so the DAG is always
+// acyclic. The same seed always produces the same DAG, including its ID.
+func NewRandomConfig(services int, edgeProb float64, seed int64) *Config {
+	rng := rand.New(rand.NewSource(seed))
+	m := &Config{Name: fmt.Sprintf("Random %v", seed), ID: guid.NewFrom(rng)}
+
+	for i := 0; i < services; i++ {
+		m.Services = append(m.Services, Service{ID: fmt.Sprintf("service-%v", i), Type: "Random", Properties: make(map[string]Property)})
+	}
+
+	for i := 0; i < services; i++ {
+		for j := i + 1; j < services; j++ {
+			if rng.Float64() < edgeProb {
+				m.Relationships = append(m.Relationships, Relationship{
+					ID:         fmt.Sprintf("service-%v to service-%v", i, j),
+					From:       m.Services[i].ID,
+					To:         m.Services[j].ID,
+					Properties: make(map[string]Property),
+				})
+			}
+		}
+	}
+
+	return m
 }
 
 // LoadFile -- New DAG info instance from the named file.
 func (m *Config) LoadFile(fileName string) (err error) {
-	contentBytes, err := ioutil.ReadFile(fileName)
+	file, err := os.Open(fileName)
 	if nil != err {
 		return
 	}
-	return m.LoadString(string(contentBytes))
+	defer file.Close()
+
+	return m.load(fileName, file)
+}
+
+// LoadReader -- New DAG info instance from the yaml read from r, decoded as it streams in.
+func (m *Config) LoadReader(r io.Reader) error {
+	return m.load("", r)
 }
 
 // LoadString -- New DAG info instance from the given yaml string.
 func (m *Config) LoadString(yamlString string) error {
-	return yamlParser.Unmarshal([]byte(yamlString), m)
+	return m.load("", strings.NewReader(yamlString))
+}
+
+// load decodes the yaml into the DAG info instance, reporting failures as a *ParseError.
+// An empty input leaves the instance empty, as with yaml.Unmarshal.
+func (m *Config) load(fileName string, r io.Reader) error {
+	m.InvalidateIndex()
+	if err := yamlParser.NewDecoder(r).Decode(m); err != nil && err != io.EOF {
+		return newParseError(fileName, err)
+	}
+	return nil
+}
+
+// NewConfigsFromString -- New DAG info instances from a yaml stream holding several documents separated by
+// "---", in order. Empty documents are skipped.
+func NewConfigsFromString(yamlString string) (configs []Config, err error) {
+	decoder := yamlParser.NewDecoder(strings.NewReader(yamlString))
+	for {
+		var m Config
+		if err = decoder.Decode(&m); err == io.EOF {
+			return configs, nil
+		} else if err != nil {
+			return nil, newParseError("", err)
+		}
+
+		if !m.IsEmpty() {
+			configs = append(configs, m)
+		}
+	}
+}
+
+// newParseError wraps a yaml error, picking the line number out of its message.
+func newParseError(fileName string, err error) *ParseError {
+	parseErr := &ParseError{FileName: fileName, Err: err}
+	if match := yamlLine.FindStringSubmatch(err.Error()); match != nil {
+		parseErr.Line, _ = strconv.Atoi(match[1])
+	}
+	return parseErr
+}
+
+// SaveString -- The DAG info instance serialized as a yaml string.
+func (m *Config) SaveString() (string, error) {
+	contentBytes, err := yamlParser.Marshal(m)
+	if nil != err {
+		return "", err
+	}
+	return string(contentBytes), nil
+}
+
+// SaveFile -- Write the DAG info instance to the named file as yaml.
+func (m *Config) SaveFile(fileName string) (err error) {
+	yamlString, err := m.SaveString()
+	if nil != err {
+		return
+	}
+	return ioutil.WriteFile(fileName, []byte(yamlString), 0644)
+}
+
+// LoadJSON -- New DAG info instance from the given JSON string. Properties are decoded into the same types as
+// from yaml, so the two formats load equal instances.
+func (m *Config) LoadJSON(jsonString string) error {
+	m.InvalidateIndex()
+
+	decoder := json.NewDecoder(strings.NewReader(jsonString))
+	decoder.UseNumber()
+	if err := decoder.Decode(m); err != nil {
+		return &ParseError{Err: err}
+	}
+
+	for i := range m.Services {
+		m.Services[i].Properties = yamlProperties(m.Services[i].Properties)
+	}
+	for i := range m.Relationships {
+		m.Relationships[i].Properties = yamlProperties(m.Relationships[i].Properties)
+	}
+
+	return nil
+}
+
+// SaveJSON -- The DAG info instance serialized as an indented JSON string.
+func (m *Config) SaveJSON() (string, error) {
+	res := Config{Name: m.Name, ID: m.ID}
+	for _, i := range m.Services {
+		i.Properties = jsonProperties(i.Properties)
+		res.Services = append(res.Services, i)
+	}
+	for _, i := range m.Relationships {
+		i.Properties = jsonProperties(i.Properties)
+		res.Relationships = append(res.Relationships, i)
+	}
+
+	contentBytes, err := json.MarshalIndent(res, "", "  ")
+	if nil != err {
+		return "", err
+	}
+	return string(contentBytes), nil
 }
 
 //IsEmpty checks if config is empty.
 func (m *Config) IsEmpty() bool {
-	return reflect.DeepEqual(Config{}, *m)
+	return reflect.DeepEqual(Config{CaseMatching: m.CaseMatching, index: m.index}, *m)
 }
 
 // ValidateModel checks if constellation has all required felids
@@ -1,8 +1,13 @@
 package constellation_test
 
 import (
+	"bytes"
+	"errors"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/microsoft/abstrakt/internal/platform/constellation"
@@ -53,6 +58,196 @@ func TestMapLoadFile(t *testing.T) {
 	assert.Truef(t, reflect.DeepEqual(&test01WantDag, dag), "Expected: %v\nGot: %v", &test01WantDag, dag)
 }
 
+func TestLoadReader(t *testing.T) {
+	contentBytes, err := ioutil.ReadFile("testdata/valid.yaml")
+	if nil != err {
+		assert.FailNow(t, err.Error())
+	}
+
+	fromReader := &constellation.Config{}
+	err = fromReader.LoadReader(bytes.NewBuffer(contentBytes))
+	assert.NoError(t, err)
+
+	fromFile := &constellation.Config{}
+	err = fromFile.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	assert.Truef(t, reflect.DeepEqual(fromFile, fromReader), "Expected: %v\nGot: %v", fromFile, fromReader)
+	assert.Truef(t, reflect.DeepEqual(&test01WantDag, fromReader), "Expected: %v\nGot: %v", &test01WantDag, fromReader)
+
+	empty := &constellation.Config{}
+	assert.NoError(t, empty.LoadReader(&bytes.Buffer{}))
+	assert.True(t, empty.IsEmpty())
+}
+
+func TestNewConfigsFromString(t *testing.T) {
+	yamlString := "Name: First\nId: first\nServices:\n- Id: a\n  Type: Test\n" +
+		"---\n" +
+		"---\n" +
+		"Name: Second\nId: second\nServices:\n- Id: b\n  Type: Test\n" +
+		"---\n" +
+		"Name: Third\nId: third\nServices:\n- Id: c\n  Type: Test\n"
+
+	configs, err := constellation.NewConfigsFromString(yamlString)
+	assert.NoError(t, err)
+	if assert.Len(t, configs, 3) {
+		assert.Equal(t, "First", configs[0].Name)
+		assert.Equal(t, "Second", configs[1].Name)
+		assert.Equal(t, "Third", configs[2].Name)
+		assert.Equal(t, "c", configs[2].Services[0].ID)
+	}
+
+	configs, err = constellation.NewConfigsFromString("")
+	assert.NoError(t, err)
+	assert.Empty(t, configs)
+
+	_, err = constellation.NewConfigsFromString("Name: First\n---\nServices: [\n")
+	var parseErr *constellation.ParseError
+	assert.True(t, errors.As(err, &parseErr))
+}
+
+func TestSaveStringRoundTrip(t *testing.T) {
+	dag := &constellation.Config{}
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	yamlString, err := dag.SaveString()
+	assert.NoError(t, err)
+
+	reloaded := &constellation.Config{}
+	err = reloaded.LoadString(yamlString)
+	assert.NoError(t, err)
+
+	assert.Truef(t, reflect.DeepEqual(dag, reloaded), "Expected: %v\nGot: %v", dag, reloaded)
+}
+
+func TestSaveFileRoundTrip(t *testing.T) {
+	tdir, err := ioutil.TempDir("./", "output-")
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer func() {
+		err = os.RemoveAll(tdir)
+		assert.NoError(t, err)
+	}()
+
+	fileName := filepath.Join(tdir, "saved.yaml")
+	err = test01WantDag.SaveFile(fileName)
+	assert.NoError(t, err)
+
+	reloaded := &constellation.Config{}
+	err = reloaded.LoadFile(fileName)
+	assert.NoError(t, err)
+
+	assert.Truef(t, reflect.DeepEqual(&test01WantDag, reloaded), "Expected: %v\nGot: %v", &test01WantDag, reloaded)
+}
+
+func TestNewRandomConfig(t *testing.T) {
+	dag := constellation.NewRandomConfig(30, 0.2, 42)
+
+	assert.Len(t, dag.Services, 30)
+	assert.NotEmpty(t, dag.Relationships)
+	assert.True(t, dag.ID.IsValid())
+	assert.NoError(t, dag.ValidateModel())
+	assert.Empty(t, dag.ServiceExists())
+	assert.Nil(t, dag.FindDuplicateIDs())
+
+	_, err := dag.TopologicalSort()
+	assert.NoError(t, err, "A random DAG should be acyclic")
+
+	assert.Truef(t, reflect.DeepEqual(dag, constellation.NewRandomConfig(30, 0.2, 42)), "The same seed should give the same DAG")
+	assert.False(t, reflect.DeepEqual(dag, constellation.NewRandomConfig(30, 0.2, 43)), "Another seed should give another DAG")
+
+	assert.Empty(t, constellation.NewRandomConfig(10, 0, 42).Relationships)
+	assert.Len(t, constellation.NewRandomConfig(10, 1, 42).Relationships, 45)
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	dag := &constellation.Config{}
+	err := dag.LoadFile("testdata/does-not-exist.yaml")
+	assert.Error(t, err)
+	assert.True(t, os.IsNotExist(err))
+
+	var parseErr *constellation.ParseError
+	assert.False(t, errors.As(err, &parseErr), "An unreadable file is not a parse error")
+}
+
+func TestLoadFileInvalidYaml(t *testing.T) {
+	tdir, err := ioutil.TempDir("./", "output-")
+	if err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	defer func() {
+		err = os.RemoveAll(tdir)
+		assert.NoError(t, err)
+	}()
+
+	fileName := filepath.Join(tdir, "invalid.yaml")
+	err = ioutil.WriteFile(fileName, []byte("Name: Test\nId: test\nServices: [\n"), 0644)
+	assert.NoError(t, err)
+
+	dag := &constellation.Config{}
+	err = dag.LoadFile(fileName)
+
+	var parseErr *constellation.ParseError
+	if !assert.True(t, errors.As(err, &parseErr)) {
+		return
+	}
+	assert.Equal(t, fileName, parseErr.FileName)
+	assert.Greater(t, parseErr.Line, 0)
+	assert.NotNil(t, errors.Unwrap(err))
+}
+
+func TestLoadStringWrongShape(t *testing.T) {
+	dag := &constellation.Config{}
+	err := dag.LoadString("Name: Test\nId: test\nServices: not-a-list\n")
+
+	var parseErr *constellation.ParseError
+	if !assert.True(t, errors.As(err, &parseErr)) {
+		return
+	}
+	assert.Empty(t, parseErr.FileName)
+	assert.Equal(t, 3, parseErr.Line)
+	assert.Contains(t, err.Error(), "cannot unmarshal")
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	dag := &constellation.Config{}
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	dag.Services[0].Properties["replicas"] = 3
+	dag.Services[0].Properties["ratio"] = 0.5
+	dag.Services[0].Properties["scale"] = 2.0
+	dag.Services[0].Properties["huge"] = 1e21
+	dag.Services[0].Properties["limits"] = map[interface{}]interface{}{"cpu": "500m", "ports": []interface{}{80, 443}}
+	dag.Relationships[0].Properties["secure"] = true
+
+	jsonString, err := dag.SaveJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, jsonString, "\"Id\": \"d6e4a5e9-696a-4626-ba7a-534d6ff450a5\"")
+	assert.Contains(t, jsonString, "\"Name\": \"Azure Event Hubs Sample\"")
+	assert.NotContains(t, jsonString, "CaseMatching")
+
+	reloaded := &constellation.Config{}
+	err = reloaded.LoadJSON(jsonString)
+	assert.NoError(t, err)
+
+	assert.Truef(t, reflect.DeepEqual(dag, reloaded), "Expected: %v\nGot: %v", dag, reloaded)
+	assert.Equal(t, 2.0, reloaded.Services[0].Properties["scale"])
+	assert.Equal(t, 3, reloaded.Services[0].Properties["replicas"])
+}
+
+func TestLoadJSONFail(t *testing.T) {
+	dag := &constellation.Config{}
+	err := dag.LoadJSON("{\"Name\": ")
+
+	var parseErr *constellation.ParseError
+	assert.True(t, errors.As(err, &parseErr))
+}
+
 func TestIsEmptyTrue(t *testing.T) {
 	dag := &constellation.Config{}
 	assert.True(t, dag.IsEmpty())
@@ -110,3 +305,74 @@ var test01WantDag constellation.Config = constellation.Config{
 		},
 	},
 }
+
+func TestNewConfigFromEdges(t *testing.T) {
+	dag, err := constellation.NewConfigFromEdges("Triangle", [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Triangle", dag.Name)
+	assert.False(t, dag.ID.IsEmpty())
+	assert.Len(t, dag.Services, 3)
+	assert.Len(t, dag.Relationships, 3)
+	assert.Empty(t, dag.ServiceExists())
+	assert.Nil(t, dag.FindDuplicateIDs())
+
+	to := dag.FindRelationshipByFromName("c")
+	assert.Len(t, to, 1)
+	assert.Equal(t, "a", to[0].To)
+}
+
+func TestNewConfigFromEdgesMixedCase(t *testing.T) {
+	dag, err := constellation.NewConfigFromEdges("Mixed", [][2]string{{"A", "b"}, {"a", "c"}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, dag.ValidateModel())
+	assert.Empty(t, dag.ServiceExists())
+	if assert.Len(t, dag.Services, 4) {
+		assert.Equal(t, "A", dag.Services[0].ID)
+		assert.Equal(t, "a", dag.Services[2].ID)
+	}
+	for _, i := range dag.Services {
+		assert.Equal(t, "Generated", i.Type)
+	}
+
+	deployable, errs := dag.IsDeployable()
+	assert.True(t, deployable)
+	assert.Empty(t, errs)
+}
+
+func TestNewConfigFromEdgesFail(t *testing.T) {
+	_, err := constellation.NewConfigFromEdges("Broken", [][2]string{{"a", ""}})
+	assert.Error(t, err)
+}
+
+func TestNewConfigFromCSV(t *testing.T) {
+	csv := "Event Generator,Azure Event Hub,Generator to Event Hubs\n" +
+		"Azure Event Hub,Event Logger,Event Hubs to Logger\n" +
+		"Event Generator, Event Logger,Generator to Logger\n"
+
+	dag, err := constellation.NewConfigFromCSV("Sample", strings.NewReader(csv))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Sample", dag.Name)
+	assert.False(t, dag.ID.IsEmpty())
+	assert.Equal(t, []string{"Event Generator", "Azure Event Hub", "Event Logger"}, []string{dag.Services[0].ID, dag.Services[1].ID, dag.Services[2].ID})
+	assert.Len(t, dag.Services, 3)
+	assert.Len(t, dag.Relationships, 3)
+	assert.Nil(t, dag.FindDuplicateIDs())
+
+	from := dag.FindRelationshipByFromName("Event Generator")
+	assert.Len(t, from, 2)
+	assert.Equal(t, "Azure Event Hub", from[0].To)
+	assert.Equal(t, "Generator to Event Hubs", from[0].Description)
+	assert.Equal(t, "Event Logger", from[1].To)
+	assert.Equal(t, "Generator to Logger", from[1].Description)
+}
+
+func TestNewConfigFromCSVFail(t *testing.T) {
+	_, err := constellation.NewConfigFromCSV("Short", strings.NewReader("a,b\n"))
+	assert.Error(t, err)
+
+	_, err = constellation.NewConfigFromCSV("Empty endpoint", strings.NewReader("a,,link\n"))
+	assert.Error(t, err)
+}
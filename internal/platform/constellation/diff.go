@@ -0,0 +1,61 @@
+package constellation
+
+import (
+	"sort"
+)
+
+// ConfigDiff -- the sorted IDs of the Services and Relationships which differ between two constellations.
+// Added entries are only in the other constellation, Removed entries only in the original one and Changed
+// entries are in both with different contents.
+type ConfigDiff struct {
+	AddedServices   []string
+	RemovedServices []string
+	ChangedServices []string
+
+	AddedRelationships   []string
+	RemovedRelationships []string
+	ChangedRelationships []string
+}
+
+// IsEmpty -- true if the two constellations hold the same Services and Relationships.
+func (d *ConfigDiff) IsEmpty() bool {
+	return len(d.AddedServices)+len(d.RemovedServices)+len(d.ChangedServices)+
+		len(d.AddedRelationships)+len(d.RemovedRelationships)+len(d.ChangedRelationships) == 0
+}
+
+// Diff compares the Services and Relationships of m with those of other by ID, so that m.Diff(other) adds
+// what other.Diff(m) removes. Contents are compared field by field, including the Properties.
+func (m *Config) Diff(other *Config) (d ConfigDiff) {
+	for _, i := range m.Services {
+		if n := indexOfService(other.Services, i.ID); n < 0 {
+			d.RemovedServices = append(d.RemovedServices, i.ID)
+		} else if !servicesEqual(i, other.Services[n]) {
+			d.ChangedServices = append(d.ChangedServices, i.ID)
+		}
+	}
+	for _, i := range other.Services {
+		if indexOfService(m.Services, i.ID) < 0 {
+			d.AddedServices = append(d.AddedServices, i.ID)
+		}
+	}
+
+	for _, i := range m.Relationships {
+		if n := indexOfRelationship(other.Relationships, i.ID); n < 0 {
+			d.RemovedRelationships = append(d.RemovedRelationships, i.ID)
+		} else if !relationshipsEqual(i, other.Relationships[n]) {
+			d.ChangedRelationships = append(d.ChangedRelationships, i.ID)
+		}
+	}
+	for _, i := range other.Relationships {
+		if indexOfRelationship(m.Relationships, i.ID) < 0 {
+			d.AddedRelationships = append(d.AddedRelationships, i.ID)
+		}
+	}
+
+	for _, IDs := range [][]string{d.AddedServices, d.RemovedServices, d.ChangedServices,
+		d.AddedRelationships, d.RemovedRelationships, d.ChangedRelationships} {
+		sort.Strings(IDs)
+	}
+
+	return
+}
@@ -0,0 +1,49 @@
+package constellation_test
+
+import (
+	"testing"
+
+	"github.com/microsoft/abstrakt/internal/platform/constellation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	original := buildConfig([]string{"a", "b", "c"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+	)
+	changed := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"c", "d"},
+	)
+	changed.Services[1].Properties["replicas"] = 3
+	changed.Relationships[0].Description = "a calls b"
+
+	d := original.Diff(changed)
+	assert.Equal(t, []string{"d"}, d.AddedServices)
+	assert.Empty(t, d.RemovedServices)
+	assert.Equal(t, []string{"b"}, d.ChangedServices)
+	assert.Equal(t, []string{"c to d"}, d.AddedRelationships)
+	assert.Equal(t, []string{"b to c"}, d.RemovedRelationships)
+	assert.Equal(t, []string{"a to b"}, d.ChangedRelationships)
+	assert.False(t, d.IsEmpty())
+
+	reverse := changed.Diff(original)
+	assert.Equal(t, d.AddedServices, reverse.RemovedServices)
+	assert.Equal(t, d.RemovedServices, reverse.AddedServices)
+	assert.Equal(t, d.ChangedServices, reverse.ChangedServices)
+	assert.Equal(t, d.AddedRelationships, reverse.RemovedRelationships)
+	assert.Equal(t, d.RemovedRelationships, reverse.AddedRelationships)
+	assert.Equal(t, d.ChangedRelationships, reverse.ChangedRelationships)
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	dag := new(constellation.Config)
+	_ = dag.LoadFile("testdata/valid.yaml")
+
+	same := new(constellation.Config)
+	_ = same.LoadFile("testdata/valid.yaml")
+
+	d := dag.Diff(same)
+	assert.True(t, d.IsEmpty())
+}
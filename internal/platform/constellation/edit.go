@@ -0,0 +1,286 @@
+package constellation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// AddService appends the Service. Returns an error if a Service with the same ID already exists.
+func (m *Config) AddService(service Service) error {
+	if existing := m.FindService(service.ID); existing != nil {
+		return fmt.Errorf("Service '%v' already exists", existing.ID)
+	}
+
+	m.Services = append(m.Services, service)
+	m.InvalidateIndex()
+	return nil
+}
+
+// RemoveService removes the Service together with every Relationship from or to it, so none are left dangling.
+// Returns an error if the Service does not exist.
+func (m *Config) RemoveService(serviceID string) error {
+	service := m.FindService(serviceID)
+	if service == nil {
+		return fmt.Errorf("Service '%v' not found", serviceID)
+	}
+
+	services := []Service{}
+	for _, i := range m.Services {
+		if i.ID != service.ID {
+			services = append(services, i)
+		}
+	}
+
+	relationships := []Relationship{}
+	for _, i := range m.Relationships {
+		if i.From != service.ID && i.To != service.ID {
+			relationships = append(relationships, i)
+		}
+	}
+
+	m.Services = services
+	m.Relationships = relationships
+	m.InvalidateIndex()
+	return nil
+}
+
+// CollapseSubgraph replaces the given Services with a single new Service. Relationships between the collapsed
+// Services are dropped, Relationships crossing the boundary are rewired to the new Service.
+// Returns the ID of the new Service, which is the given name.
+func (m *Config) CollapseSubgraph(serviceIDs []string, newName string, newType string) (string, error) {
+	if len(serviceIDs) == 0 {
+		return "", fmt.Errorf("No services to collapse")
+	}
+
+	collapsed := make(map[string]bool)
+	for _, i := range serviceIDs {
+		service := m.FindService(i)
+		if service == nil {
+			return "", fmt.Errorf("Service '%v' not found", i)
+		}
+		collapsed[service.ID] = true
+	}
+
+	if existing := m.FindService(newName); existing != nil && !collapsed[existing.ID] {
+		return "", fmt.Errorf("Service '%v' already exists", newName)
+	}
+
+	services := []Service{}
+	for _, i := range m.Services {
+		if !collapsed[i.ID] {
+			services = append(services, i)
+		}
+	}
+	m.Services = append(services, Service{ID: newName, Type: newType, Properties: make(map[string]Property)})
+
+	relationships := []Relationship{}
+	for _, i := range m.Relationships {
+		if collapsed[i.From] && collapsed[i.To] {
+			continue
+		}
+		if collapsed[i.From] {
+			i.From = newName
+		}
+		if collapsed[i.To] {
+			i.To = newName
+		}
+		relationships = append(relationships, i)
+	}
+	m.Relationships = relationships
+	m.InvalidateIndex()
+
+	return newName, nil
+}
+
+// NormalizeTypes rewrites the Type of every Service to its canonical form, looked up in canonical by the Type
+// as is or else ignoring case. Types without an entry are left unchanged.
+func (m *Config) NormalizeTypes(canonical map[string]string) {
+	folded := make(map[string]string, len(canonical))
+	for key, value := range canonical {
+		folded[strings.ToLower(key)] = value
+	}
+
+	for i := range m.Services {
+		if value, exists := canonical[m.Services[i].Type]; exists {
+			m.Services[i].Type = value
+		} else if value, exists := folded[strings.ToLower(m.Services[i].Type)]; exists {
+			m.Services[i].Type = value
+		}
+	}
+}
+
+// Sanitized returns a copy of the constellation with the Properties of every Service and Relationship emptied,
+// so the shape of a deployment can be shared without its settings.
+func (m *Config) Sanitized() *Config {
+	res := &Config{Name: m.Name, ID: m.ID, CaseMatching: m.CaseMatching}
+
+	for _, i := range m.Services {
+		i.Properties = make(map[string]Property)
+		res.Services = append(res.Services, i)
+	}
+
+	for _, i := range m.Relationships {
+		i.Properties = make(map[string]Property)
+		res.Relationships = append(res.Relationships, i)
+	}
+
+	return res
+}
+
+// Merge adds the Services and Relationships of other which m doesn't have yet, skipping identical duplicates.
+// A Service or Relationship declared by both with different contents is an error. m keeps its own Name and ID,
+// and is left unchanged on error.
+func (m *Config) Merge(other *Config) error {
+	return m.merge(other, func(a, b Service) (Service, error) {
+		return a, fmt.Errorf("Service '%v' conflicts with an existing service", b.ID)
+	})
+}
+
+// MergeNamespaced merges other as Merge does, after prefixing the IDs of its Services and Relationships and the
+// From and To of its Relationships, so constellations authored independently don't clash. other is unchanged.
+func (m *Config) MergeNamespaced(other *Config, prefix string) error {
+	namespaced := &Config{Name: other.Name, ID: other.ID}
+
+	for _, i := range other.Services {
+		i.ID = prefix + i.ID
+		namespaced.Services = append(namespaced.Services, i)
+	}
+
+	for _, i := range other.Relationships {
+		i.ID = prefix + i.ID
+		i.From = prefix + i.From
+		i.To = prefix + i.To
+		namespaced.Relationships = append(namespaced.Relationships, i)
+	}
+
+	return m.Merge(namespaced)
+}
+
+// MergeWith adds the Services and Relationships of other which m doesn't have yet. When both declare a Service
+// with the same ID but different contents, onConflict decides which Service to keep. Relationships sharing an ID
+// but differing in contents are an error, as is onConflict returning a Service with another ID. m keeps its own
+// Name and ID, and is left unchanged on error.
+func (m *Config) MergeWith(other *Config, onConflict func(a, b Service) Service) error {
+	return m.merge(other, func(a, b Service) (Service, error) {
+		return onConflict(a, b), nil
+	})
+}
+
+// merge implements MergeWith with a conflict handler which may fail.
+func (m *Config) merge(other *Config, onConflict func(a, b Service) (Service, error)) error {
+	services := append([]Service{}, m.Services...)
+	relationships := append([]Relationship{}, m.Relationships...)
+
+	// Services and Relationships taken from other get their own Properties, so other is never changed through m
+	for _, i := range other.Services {
+		n := indexOfService(services, i.ID)
+		if n < 0 {
+			i.Properties = copyProperties(i.Properties)
+			services = append(services, i)
+		} else if !servicesEqual(services[n], i) {
+			resolved, err := onConflict(services[n], i)
+			if err != nil {
+				return err
+			}
+			if resolved.ID != services[n].ID {
+				return fmt.Errorf("Service '%v' was resolved to a service with a different ID '%v'", services[n].ID, resolved.ID)
+			}
+			resolved.Properties = copyProperties(resolved.Properties)
+			services[n] = resolved
+		}
+	}
+
+	for _, i := range other.Relationships {
+		n := indexOfRelationship(relationships, i.ID)
+		if n < 0 {
+			i.Properties = copyProperties(i.Properties)
+			relationships = append(relationships, i)
+		} else if !relationshipsEqual(relationships[n], i) {
+			return fmt.Errorf("Relationship '%v' conflicts with an existing relationship", i.ID)
+		}
+	}
+
+	m.Services = services
+	m.Relationships = relationships
+	m.InvalidateIndex()
+	return nil
+}
+
+// copyProperties returns a deep copy of the Properties, including the nested maps and slices yaml decodes into.
+func copyProperties(properties map[string]Property) map[string]Property {
+	if properties == nil {
+		return nil
+	}
+
+	copied := make(map[string]Property, len(properties))
+	for key, value := range properties {
+		copied[key] = copyValue(value)
+	}
+	return copied
+}
+
+// copyValue returns a deep copy of a single Property value, see copyProperties.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		copied := make(map[interface{}]interface{}, len(v))
+		for key, i := range v {
+			copied[key] = copyValue(i)
+		}
+		return copied
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, i := range v {
+			copied[key] = copyValue(i)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for n, i := range v {
+			copied[n] = copyValue(i)
+		}
+		return copied
+	}
+
+	return value
+}
+
+// indexOfService returns the position of the Service with the given ID, or -1.
+func indexOfService(services []Service, id string) int {
+	for n, i := range services {
+		if i.ID == id {
+			return n
+		}
+	}
+	return -1
+}
+
+// indexOfRelationship returns the position of the Relationship with the given ID, or -1.
+func indexOfRelationship(relationships []Relationship, id string) int {
+	for n, i := range relationships {
+		if i.ID == id {
+			return n
+		}
+	}
+	return -1
+}
+
+// servicesEqual compares two Services field by field.
+func servicesEqual(a Service, b Service) bool {
+	return a.ID == b.ID && a.Type == b.Type && propertiesEqual(a.Properties, b.Properties)
+}
+
+// relationshipsEqual compares two Relationships field by field.
+func relationshipsEqual(a Relationship, b Relationship) bool {
+	return a.ID == b.ID && a.Description == b.Description && a.From == b.From && a.To == b.To &&
+		propertiesEqual(a.Properties, b.Properties)
+}
+
+// propertiesEqual compares two Properties maps, treating nil and empty as equal.
+func propertiesEqual(a map[string]Property, b map[string]Property) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
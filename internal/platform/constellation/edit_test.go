@@ -0,0 +1,232 @@
+package constellation_test
+
+import (
+	"testing"
+
+	"github.com/microsoft/abstrakt/internal/platform/constellation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollapseSubgraph(t *testing.T) {
+	dag := buildConfig([]string{"in", "a", "b", "c", "out"},
+		[2]string{"in", "a"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"a", "c"},
+		[2]string{"c", "out"},
+	)
+
+	id, err := dag.CollapseSubgraph([]string{"a", "b", "c"}, "cluster", "Cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, "cluster", id)
+
+	assert.Len(t, dag.Services, 3)
+	assert.Equal(t, "Cluster", dag.FindService("cluster").Type)
+	assert.Nil(t, dag.FindService("b"))
+
+	assert.Len(t, dag.Relationships, 2)
+	in := dag.FindRelationship("in to a")
+	assert.Equal(t, "in", in.From)
+	assert.Equal(t, "cluster", in.To)
+	out := dag.FindRelationship("c to out")
+	assert.Equal(t, "cluster", out.From)
+	assert.Equal(t, "out", out.To)
+}
+
+func TestCollapseSubgraphFail(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+
+	_, err := dag.CollapseSubgraph([]string{"a", "missing"}, "cluster", "Cluster")
+	assert.Error(t, err)
+
+	_, err = dag.CollapseSubgraph([]string{"a"}, "b", "Cluster")
+	assert.Error(t, err)
+
+	assert.Len(t, dag.Services, 2)
+}
+
+func TestSanitized(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	dag.Services[0].Properties["password"] = "secret"
+	dag.Relationships[0].Properties["connectionString"] = "secret"
+
+	sanitized := dag.Sanitized()
+
+	assert.Equal(t, &test01WantDag, sanitized)
+	assert.Equal(t, "secret", dag.Services[0].Properties["password"], "Original should not be modified")
+}
+
+func TestMerge(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	other := buildConfig([]string{"b", "c"}, [2]string{"a", "b"}, [2]string{"b", "c"})
+	other.Name = "Other"
+	other.ID = "other"
+
+	err := dag.Merge(other)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Test", dag.Name)
+	assert.Equal(t, "test", string(dag.ID))
+	assert.Len(t, dag.Services, 3)
+	assert.Len(t, dag.Relationships, 2)
+	assert.Nil(t, dag.FindDuplicateIDs())
+}
+
+func TestMergeCopiesProperties(t *testing.T) {
+	dag := buildConfig([]string{"a"})
+	other := buildConfig([]string{"b", "c"}, [2]string{"b", "c"})
+	other.Services[0].Properties["limits"] = map[interface{}]interface{}{"cpu": "500m"}
+	other.Services[0].Properties["ports"] = []interface{}{80}
+	other.Relationships[0].Properties["secure"] = true
+
+	err := dag.Merge(other)
+	assert.NoError(t, err)
+
+	dag.FindService("b").Properties["replicas"] = 3
+	dag.FindService("b").Properties["limits"].(map[interface{}]interface{})["cpu"] = "1"
+	dag.FindService("b").Properties["ports"].([]interface{})[0] = 8080
+	dag.FindRelationship("b to c").Properties["secure"] = false
+
+	assert.Equal(t, map[string]constellation.Property{
+		"limits": map[interface{}]interface{}{"cpu": "500m"},
+		"ports":  []interface{}{80},
+	}, other.Services[0].Properties)
+	assert.Equal(t, true, other.Relationships[0].Properties["secure"])
+}
+
+func TestMergeConflict(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	other := buildConfig([]string{"b", "c"}, [2]string{"b", "c"})
+	other.Services[0].Type = "Changed"
+
+	err := dag.Merge(other)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "'b'")
+	assert.Len(t, dag.Services, 2)
+	assert.Len(t, dag.Relationships, 1)
+}
+
+func TestMergeNamespaced(t *testing.T) {
+	dag := buildConfig([]string{"api", "db"}, [2]string{"api", "db"})
+	other := buildConfig([]string{"api", "db"}, [2]string{"api", "db"})
+	other.Services[0].Type = "Other"
+
+	assert.Error(t, dag.Merge(other), "The unprefixed IDs should clash")
+
+	err := dag.MergeNamespaced(other, "other/")
+	assert.NoError(t, err)
+	assert.Len(t, dag.Services, 4)
+	assert.Len(t, dag.Relationships, 2)
+	assert.Equal(t, "Other", dag.FindService("other/api").Type)
+	assert.Equal(t, "other/db", dag.FindRelationship("other/api to db").To)
+	assert.Empty(t, dag.ServiceExists())
+	assert.Equal(t, "api", other.Services[0].ID, "other should be unchanged")
+}
+
+func TestMergeWith(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	dag.Services[1].Properties["replicas"] = 1
+
+	other := buildConfig([]string{"b", "c"}, [2]string{"b", "c"})
+	other.Name = "Other"
+	other.Services[0].Properties["replicas"] = 3
+	other.Services[0].Properties["tier"] = "gold"
+
+	moreProperties := func(a, b constellation.Service) constellation.Service {
+		if len(b.Properties) > len(a.Properties) {
+			return b
+		}
+		return a
+	}
+
+	err := dag.MergeWith(other, moreProperties)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Test", dag.Name)
+	assert.Len(t, dag.Services, 3)
+	assert.Len(t, dag.Relationships, 2)
+	assert.Equal(t, 3, dag.FindService("b").Properties["replicas"])
+	assert.Equal(t, "gold", dag.FindService("b").Properties["tier"])
+}
+
+func TestMergeWithRelationshipConflict(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	other := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	other.Relationships[0].Description = "changed"
+
+	keep := func(a, b constellation.Service) constellation.Service { return a }
+
+	err := dag.MergeWith(other, keep)
+	assert.Error(t, err)
+	assert.Len(t, dag.Relationships, 1)
+	assert.Empty(t, dag.Relationships[0].Description)
+}
+
+func TestMergeWithRenamingConflict(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	other := buildConfig([]string{"b"})
+	other.Services[0].Type = "Changed"
+
+	rename := func(a, b constellation.Service) constellation.Service {
+		b.ID = "renamed"
+		return b
+	}
+
+	err := dag.MergeWith(other, rename)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "'b' was resolved to a service with a different ID 'renamed'")
+	}
+	assert.NotNil(t, dag.FindService("b"))
+	assert.Nil(t, dag.FindService("renamed"))
+}
+
+func TestAddService(t *testing.T) {
+	dag := buildConfig([]string{"a"})
+
+	err := dag.AddService(constellation.Service{ID: "b", Type: "Test"})
+	assert.NoError(t, err)
+	assert.Len(t, dag.Services, 2)
+	assert.NotNil(t, dag.FindService("b"))
+
+	err = dag.AddService(constellation.Service{ID: "a", Type: "Other"})
+	assert.Error(t, err)
+	assert.Len(t, dag.Services, 2)
+}
+
+func TestRemoveService(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"a", "c"},
+	)
+	dag.BuildIndex()
+
+	err := dag.RemoveService("b")
+	assert.NoError(t, err)
+	assert.Len(t, dag.Services, 2)
+	assert.Nil(t, dag.FindService("b"))
+	assert.Len(t, dag.Relationships, 1)
+	assert.Equal(t, "a to c", dag.Relationships[0].ID)
+	assert.Empty(t, dag.ServiceExists())
+
+	err = dag.RemoveService("b")
+	assert.Error(t, err)
+}
+
+func TestNormalizeTypes(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"})
+	dag.Services[0].Type = "eventhub"
+	dag.Services[1].Type = "EVENTHUB"
+	dag.Services[2].Type = "EventHub"
+	dag.Services[3].Type = "logger"
+
+	dag.NormalizeTypes(map[string]string{"eventhub": "EventHub"})
+
+	assert.Equal(t, "EventHub", dag.Services[0].Type)
+	assert.Equal(t, "EventHub", dag.Services[1].Type)
+	assert.Equal(t, "EventHub", dag.Services[2].Type)
+	assert.Equal(t, "logger", dag.Services[3].Type)
+}
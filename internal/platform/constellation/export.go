@@ -0,0 +1,230 @@
+package constellation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/microsoft/abstrakt/tools/find"
+)
+
+// DependencyRecord -- a Service and the Services it depends on
+type DependencyRecord struct {
+	Service   string   `yaml:"Service"`
+	DependsOn []string `yaml:"DependsOn"`
+}
+
+// DependencyRecords flattens the constellation into one record per Service listing the Services it depends on,
+// i.e. the From of each of its incoming Relationships.
+func (m *Config) DependencyRecords() (records []DependencyRecord) {
+	for _, i := range m.Services {
+		record := DependencyRecord{Service: i.ID}
+		seen := make(map[string]bool)
+
+		for _, j := range m.Relationships {
+			if j.To == i.ID && !seen[j.From] {
+				seen[j.From] = true
+				record.DependsOn = append(record.DependsOn, j.From)
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return
+}
+
+// WriteServicesJSONL writes every Service as a single line JSON object.
+func (m *Config) WriteServicesJSONL(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	for _, i := range m.Services {
+		i.Properties = jsonProperties(i.Properties)
+		if err := encoder.Encode(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Hash returns a hex SHA-256 over the ID, Type and Properties of the Service, for spotting which Services
+// changed between two versions of a constellation. Properties are hashed in sorted key order.
+func (s *Service) Hash() string {
+	properties := jsonProperties(s.Properties)
+	if len(properties) == 0 {
+		properties = nil
+	}
+
+	// encoding/json always writes map keys in sorted order, which makes the encoding canonical
+	content, err := json.Marshal(struct {
+		ID         string
+		Type       string
+		Properties map[string]Property
+	}{s.ID, s.Type, properties})
+	if err != nil {
+		content = []byte(fmt.Sprintf("%#v", s))
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// jsonProperties converts the nested maps yaml decodes (map[interface{}]interface{}) into maps encoding/json
+// can marshal.
+func jsonProperties(properties map[string]Property) map[string]Property {
+	if properties == nil {
+		return nil
+	}
+
+	converted := make(map[string]Property, len(properties))
+	for key, value := range properties {
+		converted[key] = jsonValue(value)
+	}
+
+	return converted
+}
+
+// jsonValue converts a single yaml decoded value, see jsonProperties. Whole float64s are written with a decimal
+// point, which encoding/json drops, so they still decode as floats.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		literal := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(literal, ".eIN") {
+			literal += ".0"
+		}
+		return json.Number(literal)
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, i := range v {
+			converted[fmt.Sprint(key)] = jsonValue(i)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for n, i := range v {
+			converted[n] = jsonValue(i)
+		}
+		return converted
+	}
+
+	return value
+}
+
+// yamlProperties converts Properties decoded from JSON (with UseNumber) into the types yaml decodes into:
+// numbers written without a decimal point or exponent to int, other numbers to float64 and nested objects to
+// map[interface{}]interface{}.
+func yamlProperties(properties map[string]Property) map[string]Property {
+	if properties == nil {
+		return nil
+	}
+
+	converted := make(map[string]Property, len(properties))
+	for key, value := range properties {
+		converted[key] = yamlValue(value)
+	}
+
+	return converted
+}
+
+// yamlValue converts a single JSON decoded value, see yamlProperties.
+func yamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if !strings.ContainsAny(v.String(), ".eE") {
+			if i, err := strconv.Atoi(v.String()); err == nil {
+				return i
+			}
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		converted := make(map[interface{}]interface{}, len(v))
+		for key, i := range v {
+			converted[key] = yamlValue(i)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for n, i := range v {
+			converted[n] = yamlValue(i)
+		}
+		return converted
+	}
+
+	return value
+}
+
+// Outline renders the constellation as an indented text outline, starting from each root Service and listing
+// each Service with its Type, indented by its depth. A Service reached again, e.g. the bottom of a diamond, is
+// listed without its subtree, marked "(see above)". Services only reachable through a cycle are not listed.
+func (m *Config) Outline() string {
+	adjacency := m.adjacency()
+	types := make(map[string]string)
+	for _, i := range m.Services {
+		types[i.ID] = i.Type
+	}
+
+	var out strings.Builder
+	listed := make(map[string]bool)
+	var walk func(id string, depth int, path map[string]bool)
+	walk = func(id string, depth int, path map[string]bool) {
+		indent := strings.Repeat("  ", depth)
+		if listed[id] {
+			fmt.Fprintf(&out, "%v%v (%v) (see above)\n", indent, id, types[id])
+			return
+		}
+		fmt.Fprintf(&out, "%v%v (%v)\n", indent, id, types[id])
+		listed[id] = true
+
+		path[id] = true
+		for _, i := range adjacency[id] {
+			if !path[i] {
+				walk(i, depth+1, path)
+			}
+		}
+		delete(path, id)
+	}
+
+	for _, i := range roots(adjacency) {
+		walk(i, 0, make(map[string]bool))
+	}
+
+	return out.String()
+}
+
+// DependencyMatrix renders a grid with the Service IDs on both axes, marking with an "X" each cell where a
+// Relationship goes from the row's Service to the column's Service, and with a "." otherwise.
+func (m *Config) DependencyMatrix() string {
+	adjacency := m.adjacency()
+
+	var out strings.Builder
+	w := tabwriter.NewWriter(&out, 0, 0, 1, ' ', 0)
+
+	header := []string{""}
+	for _, i := range m.Services {
+		header = append(header, i.ID)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for _, i := range m.Services {
+		row := []string{i.ID}
+		for _, j := range m.Services {
+			cell := "."
+			if _, exists := find.Slice(adjacency[i.ID], j.ID); exists {
+				cell = "X"
+			}
+			row = append(row, cell)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	_ = w.Flush()
+	return out.String()
+}
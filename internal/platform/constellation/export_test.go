@@ -0,0 +1,134 @@
+package constellation_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/microsoft/abstrakt/internal/platform/constellation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencyRecords(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"},
+		[2]string{"a", "c"},
+		[2]string{"b", "c"},
+		[2]string{"a", "b"},
+	)
+
+	expected := []constellation.DependencyRecord{
+		{Service: "a"},
+		{Service: "b", DependsOn: []string{"a"}},
+		{Service: "c", DependsOn: []string{"a", "b"}},
+	}
+
+	assert.Equal(t, expected, dag.DependencyRecords())
+}
+
+func TestWriteServicesJSONL(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	dag.Services[0].Properties["limits"] = map[interface{}]interface{}{"cpu": "500m"}
+
+	out := &bytes.Buffer{}
+	err = dag.WriteServicesJSONL(out)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Len(t, lines, len(dag.Services))
+
+	for _, i := range lines {
+		var service map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(i), &service), "Line should be valid JSON: %v", i)
+	}
+}
+
+func TestOutline(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	expected := `Event Generator (EventGenerator)
+  Azure Event Hub (EventHub)
+    Event Logger (EventLogger)
+`
+
+	assert.Equal(t, expected, dag.Outline())
+}
+
+func TestOutlineDiamond(t *testing.T) {
+	dag := buildConfig([]string{"top", "left", "right", "bottom", "next"},
+		[2]string{"top", "left"},
+		[2]string{"top", "right"},
+		[2]string{"left", "bottom"},
+		[2]string{"right", "bottom"},
+		[2]string{"bottom", "next"},
+	)
+
+	expected := `top (Test)
+  left (Test)
+    bottom (Test)
+      next (Test)
+  right (Test)
+    bottom (Test) (see above)
+`
+
+	assert.Equal(t, expected, dag.Outline())
+
+	var services []string
+	var edges [][2]string
+	for i := 0; i <= 40; i++ {
+		services = append(services, fmt.Sprintf("join%v", i), fmt.Sprintf("left%v", i), fmt.Sprintf("right%v", i))
+		edges = append(edges,
+			[2]string{fmt.Sprintf("join%v", i), fmt.Sprintf("left%v", i)},
+			[2]string{fmt.Sprintf("join%v", i), fmt.Sprintf("right%v", i)},
+			[2]string{fmt.Sprintf("left%v", i), fmt.Sprintf("join%v", i+1)},
+			[2]string{fmt.Sprintf("right%v", i), fmt.Sprintf("join%v", i+1)},
+		)
+	}
+	services = append(services, "join41")
+	assert.Len(t, strings.Split(buildConfig(services, edges...).Outline(), "\n"), 4*41+2)
+}
+
+func TestDependencyMatrix(t *testing.T) {
+	dag := buildConfig([]string{"web", "api", "db"},
+		[2]string{"web", "api"},
+		[2]string{"api", "db"},
+		[2]string{"web", "db"},
+	)
+
+	expected := `    web api db
+web .   X   X
+api .   .   X
+db  .   .   .
+`
+
+	assert.Equal(t, expected, dag.DependencyMatrix())
+}
+
+func TestServiceHash(t *testing.T) {
+	load := func(yamlString string) *constellation.Service {
+		dag := &constellation.Config{}
+		err := dag.LoadString("Name: Test\nId: test\nServices:\n" + yamlString)
+		assert.NoError(t, err)
+		return &dag.Services[0]
+	}
+
+	original := load("- Id: a\n  Type: Test\n  Properties:\n    replicas: 3\n    ports:\n      http: 80\n      https: 443\n")
+	reordered := load("- Id: a\n  Type: Test\n  Properties:\n    ports:\n      https: 443\n      http: 80\n    replicas: 3\n")
+	changed := load("- Id: a\n  Type: Test\n  Properties:\n    replicas: 4\n    ports:\n      http: 80\n      https: 443\n")
+
+	assert.Len(t, original.Hash(), 64)
+	assert.Equal(t, original.Hash(), reordered.Hash())
+	assert.NotEqual(t, original.Hash(), changed.Hash())
+
+	renamed := *original
+	renamed.ID = "b"
+	assert.NotEqual(t, original.Hash(), renamed.Hash())
+
+	assert.Equal(t, (&constellation.Service{ID: "a"}).Hash(), (&constellation.Service{ID: "a", Properties: map[string]constellation.Property{}}).Hash())
+}
@@ -1,32 +1,136 @@
 package constellation
 
 import (
+	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/microsoft/abstrakt/tools/find"
-	"github.com/microsoft/abstrakt/tools/guid"
 )
 
-// FindService -- Find a Service by id.
+// FindService -- Find a Service by id. The result points into m.Services, so changes made through it are kept
+// (changing the ID calls for BuildIndex or InvalidateIndex if the index is built). It is only good until
+// m.Services is next appended to or rebuilt, by AddService, RemoveService, CollapseSubgraph, the Merge methods
+// or the Load methods, after which changes made through it are lost.
 func (m *Config) FindService(serviceID string) *Service {
-	for _, val := range m.Services {
+	if idx := m.lookup(); idx != nil {
+		if found := idx.services.get(serviceID, m.CaseInsensitive()); len(found) > 0 {
+			return m.lendService(found[0])
+		}
+		return nil
+	}
+	for i, val := range m.Services {
 		if val.ID == serviceID {
-			return &val
+			return m.lendService(i)
 		}
-		if guid.TolerateMiscasedKey && strings.EqualFold(val.ID, serviceID) {
-			return &val
+		if m.CaseInsensitive() && strings.EqualFold(val.ID, serviceID) {
+			return m.lendService(i)
 		}
 	}
 	return nil
 }
 
-// FindRelationship -- Find a Relationship by id.
+// Resolve -- Resolve a reference to a Service, preferring an exact ID match over one differing only in case.
+// The result points into m.Services, the same as for FindService.
+func (m *Config) Resolve(ref string) (*Service, error) {
+	if idx := m.lookup(); idx != nil {
+		found := idx.services.get(ref, false)
+		if len(found) == 0 && m.CaseInsensitive() {
+			found = idx.services.get(ref, true)
+		}
+		if len(found) > 0 {
+			return m.lendService(found[0]), nil
+		}
+		return nil, fmt.Errorf("No service matches '%v'", ref)
+	}
+	for i, val := range m.Services {
+		if val.ID == ref {
+			return m.lendService(i), nil
+		}
+	}
+	if m.CaseInsensitive() {
+		for i, val := range m.Services {
+			if strings.EqualFold(val.ID, ref) {
+				return m.lendService(i), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("No service matches '%v'", ref)
+}
+
+// FindUntypedServices -- Find the Services without a Type.
+func (m *Config) FindUntypedServices() (res []*Service) {
+	for i := range m.Services {
+		if m.Services[i].Type == "" {
+			res = append(res, &m.Services[i])
+		}
+	}
+	return
+}
+
+// GroupByNamePrefix -- Group the Services by the part of their ID before the first separator.
+// Services without the separator are grouped under their full ID.
+func (m *Config) GroupByNamePrefix(sep string) map[string][]*Service {
+	groups := make(map[string][]*Service)
+	for i := range m.Services {
+		prefix := namePrefix(m.Services[i].ID, sep)
+		groups[prefix] = append(groups[prefix], &m.Services[i])
+	}
+	return groups
+}
+
+// namePrefix returns the part of the ID before the first separator, or the whole ID.
+func namePrefix(id string, sep string) string {
+	return strings.SplitN(id, sep, 2)[0]
+}
+
+// FindRelationship -- Find a Relationship by id. The result points into m.Relationships, so changes made through
+// it are kept (changing the ID, From or To calls for BuildIndex or InvalidateIndex if the index is built). It is
+// only good until m.Relationships is next rebuilt, by RemoveService, CollapseSubgraph, the Merge methods or the
+// Load methods, after which changes made through it are lost.
 func (m *Config) FindRelationship(relationshipID string) *Relationship {
-	for _, val := range m.Relationships {
+	if idx := m.lookup(); idx != nil {
+		if found := idx.relationships.get(relationshipID, m.CaseInsensitive()); len(found) > 0 {
+			return m.lendRelationship(found[0])
+		}
+		return nil
+	}
+	for i, val := range m.Relationships {
 		if val.ID == relationshipID {
-			return &val
-		} else if guid.TolerateMiscasedKey && strings.EqualFold(val.ID, relationshipID) {
-			return &val
+			return m.lendRelationship(i)
+		} else if m.CaseInsensitive() && strings.EqualFold(val.ID, relationshipID) {
+			return m.lendRelationship(i)
+		}
+	}
+	return nil
+}
+
+// Key -- a composite key of From, To and ID, addressing a Relationship even when its ID alone is shared. Each
+// field is quoted, so whatever they contain two Relationships only share a key if all three fields are the same.
+func (r *Relationship) Key() string {
+	return fmt.Sprintf("%q %q %q", r.From, r.To, r.ID)
+}
+
+// FindRelationshipByKey -- Find a Relationship by its composite Key. The result points into m.Relationships, the
+// same as for FindRelationship.
+func (m *Config) FindRelationshipByKey(key string) *Relationship {
+	for i, val := range m.Relationships {
+		if val.Key() == key {
+			return m.lendRelationship(i)
+		} else if m.CaseInsensitive() && strings.EqualFold(val.Key(), key) {
+			return m.lendRelationship(i)
+		}
+	}
+	return nil
+}
+
+// FindFirstRelationship -- Find the first Relationship matching the predicate. The result points into
+// m.Relationships, the same as for FindRelationship.
+func (m *Config) FindFirstRelationship(pred func(*Relationship) bool) *Relationship {
+	for i := range m.Relationships {
+		if pred(&m.Relationships[i]) {
+			return m.lendRelationship(i)
 		}
 	}
 	return nil
@@ -34,11 +138,17 @@ func (m *Config) FindRelationship(relationshipID string) *Relationship {
 
 // FindRelationshipByToName -- Find a Relationship by the name that is the target of the rel.
 func (m *Config) FindRelationshipByToName(relationshipToName string) (res []Relationship) {
+	if idx := m.lookup(); idx != nil {
+		for _, i := range idx.to.get(relationshipToName, m.CaseInsensitive()) {
+			res = append(res, m.Relationships[i])
+		}
+		return
+	}
 	for _, val := range m.Relationships {
 		// try first for an exact match
 		if val.To == relationshipToName {
 			res = append(res, val)
-		} else if guid.TolerateMiscasedKey && strings.EqualFold(string(val.To), relationshipToName) {
+		} else if m.CaseInsensitive() && strings.EqualFold(string(val.To), relationshipToName) {
 			res = append(res, val)
 		}
 	}
@@ -47,17 +157,96 @@ func (m *Config) FindRelationshipByToName(relationshipToName string) (res []Rela
 
 // FindRelationshipByFromName -- Find a Relationship by the name that is the source of the rel.
 func (m *Config) FindRelationshipByFromName(relationshipFromName string) (res []Relationship) {
+	if idx := m.lookup(); idx != nil {
+		for _, i := range idx.from.get(relationshipFromName, m.CaseInsensitive()) {
+			res = append(res, m.Relationships[i])
+		}
+		return
+	}
 	for _, val := range m.Relationships {
 		// try first for an exact match
 		if val.From == relationshipFromName {
 			res = append(res, val)
-		} else if guid.TolerateMiscasedKey && strings.EqualFold(string(val.From), relationshipFromName) {
+		} else if m.CaseInsensitive() && strings.EqualFold(string(val.From), relationshipFromName) {
 			res = append(res, val)
 		}
 	}
 	return
 }
 
+// OutgoingRelationships -- Find the Relationships from the given Service, an empty slice (never nil) if there
+// are none.
+func (m *Config) OutgoingRelationships(serviceID string) []Relationship {
+	return append([]Relationship{}, m.FindRelationshipByFromName(serviceID)...)
+}
+
+// IncomingRelationships -- Find the Relationships to the given Service, an empty slice (never nil) if there
+// are none.
+func (m *Config) IncomingRelationships(serviceID string) []Relationship {
+	return append([]Relationship{}, m.FindRelationshipByToName(serviceID)...)
+}
+
+// Neighbors -- Find the sorted IDs of the Services directly connected to the given Service, in either direction.
+func (m *Config) Neighbors(serviceID string) []string {
+	neighbors := make(map[string]bool)
+	for _, i := range m.OutgoingRelationships(serviceID) {
+		neighbors[i.To] = true
+	}
+	for _, i := range m.IncomingRelationships(serviceID) {
+		neighbors[i.From] = true
+	}
+	return sortedSet(neighbors)
+}
+
+// RootServices -- Find the Services which are never the To of a Relationship, in declaration order.
+func (m *Config) RootServices() (res []Service) {
+	targets := make(map[string]bool)
+	for _, i := range m.Relationships {
+		targets[i.To] = true
+	}
+
+	for _, i := range m.Services {
+		if !targets[i.ID] {
+			res = append(res, i)
+		}
+	}
+	return
+}
+
+// LeafServices -- Find the Services which are never the From of a Relationship, in declaration order.
+func (m *Config) LeafServices() (res []Service) {
+	sources := make(map[string]bool)
+	for _, i := range m.Relationships {
+		sources[i.From] = true
+	}
+
+	for _, i := range m.Services {
+		if !sources[i.ID] {
+			res = append(res, i)
+		}
+	}
+	return
+}
+
+// FindUnlinkedSameTypePairs -- Find the pairs of Services sharing a Type with no Relationship between them in
+// either direction. Pairs are in declaration order, the earlier Service first.
+func (m *Config) FindUnlinkedSameTypePairs() (pairs [][2]string) {
+	linked := make(map[[2]string]bool)
+	for _, i := range m.Relationships {
+		linked[[2]string{i.From, i.To}] = true
+		linked[[2]string{i.To, i.From}] = true
+	}
+
+	for i, a := range m.Services {
+		for _, b := range m.Services[i+1:] {
+			if a.Type == b.Type && a.ID != b.ID && !linked[[2]string{a.ID, b.ID}] {
+				pairs = append(pairs, [2]string{a.ID, b.ID})
+			}
+		}
+	}
+	return
+}
+
 // FindDuplicateIDs checks for duplicate Relationship and Service IDs in a constellation file.
 func (m *Config) FindDuplicateIDs() (duplicates []string) {
 	IDs := []string{string(m.ID)}
@@ -83,6 +272,32 @@ func (m *Config) FindDuplicateIDs() (duplicates []string) {
 	return
 }
 
+// FindDuplicates reports every ID appearing more than once across the Config, its Services and its
+// Relationships combined (IDs are meant to be globally unique), and every non-empty Relationship Description
+// shared by more than one Relationship (Services have no name besides their ID). Each duplicate is reported
+// once, in order of its first repetition.
+func (m *Config) FindDuplicates() (dupIDs []string, dupNames []string) {
+	for _, i := range m.FindDuplicateIDs() {
+		if _, exists := find.Slice(dupIDs, i); !exists {
+			dupIDs = append(dupIDs, i)
+		}
+	}
+
+	var names []string
+	for _, i := range m.Relationships {
+		if i.Description == "" {
+			continue
+		}
+		if _, exists := find.Slice(names, i.Description); !exists {
+			names = append(names, i.Description)
+		} else if _, exists := find.Slice(dupNames, i.Description); !exists {
+			dupNames = append(dupNames, i.Description)
+		}
+	}
+
+	return
+}
+
 // ServiceExists loops through each Relationship and checks if the services are declared.
 func (m *Config) ServiceExists() (missing map[string][]string) {
 	missing = make(map[string][]string)
@@ -106,3 +321,117 @@ func (m *Config) ServiceExists() (missing map[string][]string) {
 
 	return
 }
+
+// AllPropertyKeys returns the sorted set of Property keys used across all Services.
+func (m *Config) AllPropertyKeys() (keys []string) {
+	seen := make(map[string]bool)
+
+	for _, i := range m.Services {
+		for key := range i.Properties {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	return
+}
+
+// FindStructuralTwins groups the Services whose Relationships point to exactly the same set of target Services.
+// Services without outgoing Relationships are not considered twins.
+func (m *Config) FindStructuralTwins() (twins [][]string) {
+	targets := make(map[string][]string)
+
+	for _, i := range m.Relationships {
+		_, exists := find.Slice(targets[i.From], i.To)
+		if !exists {
+			targets[i.From] = append(targets[i.From], i.To)
+		}
+	}
+
+	groups := make(map[string][]string)
+	var keys []string
+
+	for _, i := range m.serviceIDs() {
+		if len(targets[i]) == 0 {
+			continue
+		}
+
+		sort.Strings(targets[i])
+		key := strings.Join(targets[i], "|")
+		if _, exists := groups[key]; !exists {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	for _, key := range keys {
+		if len(groups[key]) > 1 {
+			twins = append(twins, groups[key])
+		}
+	}
+
+	return
+}
+
+// FindPropertyReferences resolves a Property holding the ID of another Service. Returns a map from each Service
+// having the Property to the ID of the referenced Service, dangling references map to an empty ID.
+func (m *Config) FindPropertyReferences(propertyKey string) map[string]string {
+	references := make(map[string]string)
+
+	for _, i := range m.Services {
+		value, exists := i.Properties[propertyKey]
+		if !exists {
+			continue
+		}
+
+		references[i.ID] = ""
+		if target, ok := value.(string); ok {
+			if service := m.FindService(target); service != nil {
+				references[i.ID] = service.ID
+			}
+		}
+	}
+
+	return references
+}
+
+// FindConflictingParallelEdges groups Relationships connecting the same From and To Services when at least two
+// of them have different values for the same Property key.
+func (m *Config) FindConflictingParallelEdges() (conflicts [][]*Relationship) {
+	parallel := make(map[string][]*Relationship)
+	var keys []string
+
+	for i := range m.Relationships {
+		key := m.Relationships[i].From + "|" + m.Relationships[i].To
+		if _, exists := parallel[key]; !exists {
+			keys = append(keys, key)
+		}
+		parallel[key] = append(parallel[key], &m.Relationships[i])
+	}
+
+	for _, key := range keys {
+		if propertiesConflict(parallel[key]) {
+			conflicts = append(conflicts, parallel[key])
+		}
+	}
+
+	return
+}
+
+// propertiesConflict checks if any two Relationships disagree on the value of a shared Property key.
+func propertiesConflict(relationships []*Relationship) bool {
+	for i := range relationships {
+		for j := i + 1; j < len(relationships); j++ {
+			for key, value := range relationships[i].Properties {
+				other, exists := relationships[j].Properties[key]
+				if exists && !reflect.DeepEqual(value, other) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
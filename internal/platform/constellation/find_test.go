@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/microsoft/abstrakt/internal/platform/constellation"
+	"github.com/microsoft/abstrakt/tools/guid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -92,6 +93,135 @@ func TestForDuplicatIDsFail(t *testing.T) {
 	assert.Equal(t, 1, len(duplicates))
 }
 
+func TestFindRelationshipByKey(t *testing.T) {
+	dag := new(constellation.Config)
+	_ = dag.LoadFile("testdata/valid.yaml")
+
+	key := `"Event Generator" "Azure Event Hub" "Generator to Event Hubs Link"`
+	rel := dag.FindRelationshipByKey(key)
+	if assert.NotNil(t, rel) {
+		assert.Equal(t, "Event Generator to Event Hub connection", rel.Description)
+		assert.Equal(t, key, rel.Key())
+	}
+
+	assert.NotNil(t, dag.FindRelationshipByKey(`"event generator" "azure event hub" "generator to event hubs link"`))
+	assert.Nil(t, dag.FindRelationshipByKey(`"Event Generator" "Azure Event Hub" ""`))
+
+	shared := dag.Relationships[0]
+	shared.To = "Event Logger"
+	dag.Relationships = append(dag.Relationships, shared)
+	if rel := dag.FindRelationshipByKey(shared.Key()); assert.NotNil(t, rel) {
+		assert.Equal(t, "Event Logger", rel.To)
+	}
+}
+
+func TestRelationshipKeyCollision(t *testing.T) {
+	dag := buildConfig([]string{"a", "a|b", "b", "b|c", "c"})
+	dag.Relationships = []constellation.Relationship{
+		{ID: "d", From: "a|b", To: "c", Description: "first"},
+		{ID: "d", From: "a", To: "b|c", Description: "second"},
+		{ID: "c|d", From: "a", To: "b", Description: "third"},
+		{ID: "d", From: `a" "b`, To: "c", Description: "quoted"},
+	}
+
+	keys := make(map[string]bool)
+	for _, i := range dag.Relationships {
+		assert.False(t, keys[i.Key()], "%v shares its key", i.Description)
+		keys[i.Key()] = true
+
+		if found := dag.FindRelationshipByKey(i.Key()); assert.NotNil(t, found) {
+			assert.Equal(t, i.Description, found.Description)
+		}
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	dag := buildConfig([]string{"source", "middle", "sink", "other"},
+		[2]string{"source", "middle"},
+		[2]string{"source", "sink"},
+		[2]string{"middle", "sink"},
+		[2]string{"other", "middle"},
+	)
+
+	assert.Len(t, dag.OutgoingRelationships("source"), 2)
+	assert.Empty(t, dag.IncomingRelationships("source"))
+	assert.NotNil(t, dag.IncomingRelationships("source"))
+	assert.Equal(t, []string{"middle", "sink"}, dag.Neighbors("source"))
+
+	assert.Empty(t, dag.OutgoingRelationships("sink"))
+	assert.NotNil(t, dag.OutgoingRelationships("sink"))
+	assert.Len(t, dag.IncomingRelationships("sink"), 2)
+	assert.Equal(t, []string{"middle", "source"}, dag.Neighbors("sink"))
+
+	outgoing := dag.OutgoingRelationships("middle")
+	assert.Len(t, outgoing, 1)
+	assert.Equal(t, "sink", outgoing[0].To)
+	assert.Len(t, dag.IncomingRelationships("middle"), 2)
+	assert.Equal(t, []string{"other", "sink", "source"}, dag.Neighbors("middle"))
+
+	assert.Empty(t, dag.OutgoingRelationships("missing"))
+	assert.NotNil(t, dag.OutgoingRelationships("missing"))
+	assert.Empty(t, dag.IncomingRelationships("missing"))
+	assert.NotNil(t, dag.IncomingRelationships("missing"))
+	assert.Empty(t, dag.Neighbors("missing"))
+}
+
+func TestRootAndLeafServices(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+	)
+
+	roots := dag.RootServices()
+	if assert.Len(t, roots, 1) {
+		assert.Equal(t, "a", roots[0].ID)
+	}
+	leaves := dag.LeafServices()
+	if assert.Len(t, leaves, 1) {
+		assert.Equal(t, "c", leaves[0].ID)
+	}
+
+	dag.Services = append(dag.Services, constellation.Service{ID: "isolated", Type: "Test"})
+	assert.Len(t, dag.RootServices(), 2)
+	assert.Equal(t, "isolated", dag.RootServices()[1].ID)
+	assert.Len(t, dag.LeafServices(), 2)
+	assert.Equal(t, "isolated", dag.LeafServices()[1].ID)
+}
+
+func TestFindUnlinkedSameTypePairs(t *testing.T) {
+	dag := buildConfig([]string{"orders-db", "api", "users-db", "cache", "cache-replica"},
+		[2]string{"api", "orders-db"},
+		[2]string{"api", "users-db"},
+		[2]string{"cache-replica", "cache"},
+	)
+	dag.Services[0].Type = "db"
+	dag.Services[2].Type = "db"
+	dag.Services[3].Type = "cache"
+	dag.Services[4].Type = "cache"
+
+	assert.Equal(t, [][2]string{{"orders-db", "users-db"}}, dag.FindUnlinkedSameTypePairs())
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "sync", From: "users-db", To: "orders-db"})
+	assert.Empty(t, dag.FindUnlinkedSameTypePairs())
+}
+
+func TestFindDuplicates(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "a", "a"},
+		[2]string{"a", "b"},
+		[2]string{"b", "a"},
+	)
+	dag.Relationships[0].Description = "link"
+	dag.Relationships[1].Description = "link"
+
+	dupIDs, dupNames := dag.FindDuplicates()
+	assert.Equal(t, []string{"a"}, dupIDs)
+	assert.Equal(t, []string{"link"}, dupNames)
+
+	dupIDs, dupNames = buildConfig([]string{"a", "b"}, [2]string{"a", "b"}).FindDuplicates()
+	assert.Nil(t, dupIDs)
+	assert.Nil(t, dupNames)
+}
+
 func TestServicesExistsFail(t *testing.T) {
 	expected := "Azure Event Hub"
 	testData := new(constellation.Config)
@@ -154,3 +284,238 @@ func TestSchemaMissingRelationshipID(t *testing.T) {
 	err = testData.ValidateModel()
 	assert.Error(t, err, "Model validation should be invalid")
 }
+
+func TestAllPropertyKeys(t *testing.T) {
+	dag := &constellation.Config{
+		Services: []constellation.Service{
+			{ID: "Web", Properties: map[string]constellation.Property{"replicas": 2, "port": 80}},
+			{ID: "Api", Properties: map[string]constellation.Property{"port": 8080, "tier": "gold"}},
+			{ID: "Db", Properties: map[string]constellation.Property{"storage": "10Gi"}},
+		},
+	}
+
+	assert.Equal(t, []string{"port", "replicas", "storage", "tier"}, dag.AllPropertyKeys())
+}
+
+func TestFindFirstRelationship(t *testing.T) {
+	dag := new(constellation.Config)
+	_ = dag.LoadFile("testdata/valid.yaml")
+
+	dag.Relationships[1].Properties["async"] = true
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{
+		ID:         "Generator to Logger Link",
+		From:       "Event Generator",
+		To:         "Event Logger",
+		Properties: map[string]constellation.Property{"async": true},
+	})
+
+	isAsync := func(r *constellation.Relationship) bool {
+		async, ok := r.Properties["async"].(bool)
+		return ok && async
+	}
+
+	rel := dag.FindFirstRelationship(isAsync)
+	assert.NotNil(t, rel)
+	assert.Equal(t, "Event Hubs to Event Logger Link", rel.ID)
+
+	rel = dag.FindFirstRelationship(func(r *constellation.Relationship) bool { return r.From == "missing" })
+	assert.Nil(t, rel)
+}
+
+func TestFindStructuralTwins(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "x", "y"},
+		[2]string{"a", "x"},
+		[2]string{"a", "y"},
+		[2]string{"b", "y"},
+		[2]string{"b", "x"},
+		[2]string{"c", "x"},
+	)
+
+	assert.Equal(t, [][]string{{"a", "b"}}, dag.FindStructuralTwins())
+}
+
+func TestFindPropertyReferences(t *testing.T) {
+	dag := new(constellation.Config)
+	_ = dag.LoadFile("testdata/valid.yaml")
+
+	dag.Services[1].Properties["backup"] = "Event Logger"
+	dag.Services[2].Properties["backup"] = "Missing Service"
+
+	references := dag.FindPropertyReferences("backup")
+
+	expected := map[string]string{
+		"Azure Event Hub": "Event Logger",
+		"Event Logger":    "",
+	}
+	assert.Equal(t, expected, references)
+}
+
+func TestFindUntypedServices(t *testing.T) {
+	dag := &constellation.Config{
+		Services: []constellation.Service{
+			{ID: "Typed", Type: "EventHub"},
+			{ID: "Untyped"},
+		},
+	}
+
+	untyped := dag.FindUntypedServices()
+	assert.Len(t, untyped, 1)
+	assert.Equal(t, "Untyped", untyped[0].ID)
+}
+
+func TestFindConflictingParallelEdges(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"},
+		[2]string{"a", "b"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"b", "c"},
+	)
+	dag.Relationships[0].Properties["protocol"] = "http"
+	dag.Relationships[1].Properties["protocol"] = "amqp"
+	dag.Relationships[2].Properties["protocol"] = "http"
+	dag.Relationships[3].Properties["protocol"] = "http"
+	dag.Relationships[3].Properties["retries"] = 3
+
+	conflicts := dag.FindConflictingParallelEdges()
+	assert.Len(t, conflicts, 1)
+	assert.Len(t, conflicts[0], 2)
+	assert.Equal(t, "a", conflicts[0][0].From)
+	assert.Equal(t, "b", conflicts[0][1].To)
+}
+
+func TestResolve(t *testing.T) {
+	dag := new(constellation.Config)
+	_ = dag.LoadFile("testdata/valid.yaml")
+	dag.Services = append(dag.Services, constellation.Service{ID: "event logger", Type: "Other"})
+
+	service, err := dag.Resolve("Event Logger")
+	assert.NoError(t, err)
+	assert.Equal(t, "EventLogger", service.Type, "Exact match should be preferred")
+
+	service, err = dag.Resolve("azure EVENT hub")
+	assert.NoError(t, err)
+	assert.Equal(t, "Azure Event Hub", service.ID)
+
+	service, err = dag.Resolve("Missing")
+	assert.Error(t, err)
+	assert.Nil(t, service)
+}
+
+func TestMiscasedFinding(t *testing.T) {
+	dag := new(constellation.Config)
+	_ = dag.LoadFile("testdata/valid.yaml")
+
+	service := dag.FindService("azure event hub")
+	assert.NotNil(t, service)
+	assert.Equal(t, "Azure Event Hub", service.ID)
+
+	rel := dag.FindRelationship("generator TO event hubs link")
+	assert.NotNil(t, rel)
+	assert.Equal(t, "Generator to Event Hubs Link", rel.ID)
+
+	to := dag.FindRelationshipByToName("EVENT LOGGER")
+	assert.Len(t, to, 1)
+	assert.Equal(t, "Event Hubs to Event Logger Link", to[0].ID)
+
+	from := dag.FindRelationshipByFromName("event generator")
+	assert.Len(t, from, 1)
+	assert.Equal(t, "Generator to Event Hubs Link", from[0].ID)
+}
+
+func TestGroupByNamePrefix(t *testing.T) {
+	dag := buildConfig([]string{"team-a/web", "team-a/db", "team-b/web", "shared"})
+
+	groups := dag.GroupByNamePrefix("/")
+	assert.Len(t, groups, 3)
+
+	assert.Len(t, groups["team-a"], 2)
+	assert.Equal(t, "team-a/web", groups["team-a"][0].ID)
+	assert.Equal(t, "team-a/db", groups["team-a"][1].ID)
+	assert.Len(t, groups["team-b"], 1)
+	assert.Len(t, groups["shared"], 1)
+}
+
+func TestCaseInsensitivePerInstance(t *testing.T) {
+	strict := buildConfig([]string{"abc"})
+	strict.CaseMatching = constellation.ExactCase
+	lenient := buildConfig([]string{"abc"})
+	lenient.CaseMatching = constellation.AnyCase
+
+	assert.Nil(t, strict.FindService("ABC"))
+	assert.NotNil(t, lenient.FindService("ABC"))
+
+	_, err := strict.Resolve("ABC")
+	assert.Error(t, err)
+	_, err = lenient.Resolve("ABC")
+	assert.NoError(t, err)
+
+	loaded := new(constellation.Config)
+	err = loaded.LoadString("Name: Test\nId: test\nServices:\n- Id: abc\n  Type: Test\n")
+	assert.NoError(t, err)
+	assert.Equal(t, guid.TolerateMiscasedKey, loaded.CaseInsensitive())
+}
+
+func TestCaseInsensitiveDefault(t *testing.T) {
+	literal := &constellation.Config{Name: "Test", ID: "test", Services: []constellation.Service{{ID: "abc", Type: "Test"}}}
+	assert.Equal(t, guid.TolerateMiscasedKey, literal.CaseInsensitive())
+	assert.Equal(t, guid.TolerateMiscasedKey, literal.FindService("ABC") != nil)
+	assert.Equal(t, guid.TolerateMiscasedKey, new(constellation.Config).CaseInsensitive())
+
+	fromEdges, err := constellation.NewConfigFromEdges("Test", [][2]string{{"abc", "def"}})
+	assert.NoError(t, err)
+	assert.Equal(t, guid.TolerateMiscasedKey, fromEdges.FindService("ABC") != nil)
+
+	assert.Equal(t, guid.TolerateMiscasedKey, constellation.NewRandomConfig(2, 1, 1).CaseInsensitive())
+
+	other, err := constellation.NewConfigFromEdges("Other", [][2]string{{"def", "ghi"}})
+	assert.NoError(t, err)
+	assert.NoError(t, literal.Merge(other))
+	assert.Equal(t, guid.TolerateMiscasedKey, literal.FindService("DEF") != nil)
+}
+
+func TestFindReturnsStoredElement(t *testing.T) {
+	dag := new(constellation.Config)
+	_ = dag.LoadFile("testdata/valid.yaml")
+
+	dag.FindService("Event Logger").Properties["replicas"] = 2
+	assert.Equal(t, 2, dag.FindService("Event Logger").Properties["replicas"])
+
+	dag.FindService("Azure Event Hub").Type = "ServiceBus"
+	assert.Equal(t, "ServiceBus", dag.FindService("azure event hub").Type)
+
+	service, err := dag.Resolve("Event Generator")
+	assert.NoError(t, err)
+	service.Type = "Changed"
+	assert.Equal(t, "Changed", dag.FindService("Event Generator").Type)
+
+	dag.FindRelationship("Generator to Event Hubs Link").Description = "changed"
+	assert.Equal(t, "changed", dag.FindRelationship("Generator to Event Hubs Link").Description)
+
+	dag.FindRelationshipByKey(dag.Relationships[1].Key()).Description = "by key"
+	assert.Equal(t, "by key", dag.Relationships[1].Description)
+
+	dag.FindFirstRelationship(func(r *constellation.Relationship) bool { return r.To == "Event Logger" }).Description = "first"
+	assert.Equal(t, "first", dag.Relationships[1].Description)
+
+	dag.BuildIndex()
+	dag.FindService("Event Logger").Type = "Indexed"
+	assert.Equal(t, "Indexed", dag.Services[2].Type)
+}
+
+func TestFindPointerKeepsIndexConsistent(t *testing.T) {
+	dag := new(constellation.Config)
+	_ = dag.LoadFile("testdata/valid.yaml")
+	dag.BuildIndex()
+
+	dag.FindService("Event Logger").ID = "Logger"
+	dag.BuildIndex()
+	assert.Nil(t, dag.FindService("Event Logger"))
+	if assert.NotNil(t, dag.FindService("logger")) {
+		assert.Equal(t, "Logger", dag.FindService("logger").ID)
+	}
+
+	dag.FindRelationship("Generator to Event Hubs Link").To = "Logger"
+	dag.BuildIndex()
+	assert.Len(t, dag.FindRelationshipByToName("Logger"), 1)
+	assert.Empty(t, dag.FindRelationshipByToName("Azure Event Hub"))
+}
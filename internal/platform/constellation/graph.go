@@ -3,6 +3,7 @@ package constellation
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/awalterschulze/gographviz"
@@ -64,3 +65,96 @@ func (readGraph *Config) GenerateGraph(out io.Writer) (string, error) {
 	// Produce resulting graph in dot notation format
 	return g.String(), nil
 }
+
+// TypeColors assigns a color from the palette to every distinct Service Type, in sorted Type order, reusing
+// the palette from the start when there are more Types than colors. Returns an empty map for an empty palette.
+func (readGraph *Config) TypeColors(palette []string) map[string]string {
+	colors := make(map[string]string)
+	if len(palette) == 0 {
+		return colors
+	}
+
+	var types []string
+	for _, v := range readGraph.Services {
+		if _, exists := colors[v.Type]; !exists {
+			colors[v.Type] = ""
+			types = append(types, v.Type)
+		}
+	}
+
+	sort.Strings(types)
+	for i, t := range types {
+		colors[t] = palette[i%len(palette)]
+	}
+
+	return colors
+}
+
+// ToDot renders the constellation as a GraphViz digraph without going through gographviz. Nodes are keyed by
+// Service ID and labeled with the ID and Type, edges are labeled with the Relationship ID. Endpoints which don't
+// resolve to a Service are drawn as dashed red nodes so the gap is easy to spot.
+func (readGraph *Config) ToDot() string {
+	return readGraph.dot(nil)
+}
+
+// ToDOTRanked renders the constellation like ToDot, additionally pinning the Services of each deployment level
+// to the same rank so GraphViz lays them out in clean layers. Returns an error if the constellation is cyclic.
+func (readGraph *Config) ToDOTRanked() (string, error) {
+	levels, err := readGraph.levels()
+	if err != nil {
+		return "", err
+	}
+
+	// each rank lists its Services by ID
+	var ranks [][]string
+	for _, id := range readGraph.serviceIDs() {
+		for len(ranks) <= levels[id] {
+			ranks = append(ranks, nil)
+		}
+		ranks[levels[id]] = append(ranks[levels[id]], id)
+	}
+
+	return readGraph.dot(ranks), nil
+}
+
+// dot writes the digraph for ToDot and ToDOTRanked, with a rank=same group for each entry of ranks.
+func (readGraph *Config) dot(ranks [][]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %q {\n", readGraph.Name)
+	b.WriteString("  rankdir=LR;\n")
+
+	declared := make(map[string]bool)
+	for _, v := range readGraph.Services {
+		declared[v.ID] = true
+		fmt.Fprintf(&b, "  %q [label=%q];\n", v.ID, v.ID+"\n"+v.Type)
+	}
+
+	dangling := make(map[string]bool)
+	for _, v := range readGraph.Relationships {
+		for _, id := range []string{v.From, v.To} {
+			if !declared[id] {
+				dangling[id] = true
+			}
+		}
+	}
+	for _, id := range sortedSet(dangling) {
+		fmt.Fprintf(&b, "  %q [label=%q, style=dashed, color=red];\n", id, id+"\n(missing)")
+	}
+
+	for _, rank := range ranks {
+		b.WriteString("  { rank=same;")
+		for _, id := range rank {
+			fmt.Fprintf(&b, " %q;", id)
+		}
+		b.WriteString(" }\n")
+	}
+
+	for _, v := range readGraph.Relationships {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", v.From, v.To, v.ID)
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
@@ -2,6 +2,7 @@ package constellation_test
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/microsoft/abstrakt/internal/platform/constellation"
@@ -39,3 +40,61 @@ const test02ConstGraphString = `digraph Azure_Event_Hubs_Sample {
 
 }
 `
+
+func TestTypeColors(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"})
+	dag.Services[0].Type = "EventHub"
+	dag.Services[1].Type = "EventGenerator"
+	dag.Services[2].Type = "EventLogger"
+	dag.Services[3].Type = "EventHub"
+
+	colors := dag.TypeColors([]string{"red", "blue"})
+
+	expected := map[string]string{
+		"EventGenerator": "red",
+		"EventHub":       "blue",
+		"EventLogger":    "red",
+	}
+	assert.Equal(t, expected, colors)
+	assert.Equal(t, colors, dag.TypeColors([]string{"red", "blue"}))
+
+	assert.Empty(t, dag.TypeColors(nil))
+}
+
+func TestToDot(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"},
+		[2]string{"a", "b"},
+		[2]string{"b", "ghost"},
+	)
+	dag.Services[0].Type = "EventHub"
+
+	dot := dag.ToDot()
+
+	assert.True(t, strings.HasPrefix(dot, "digraph \"Test\" {\n"))
+	assert.Contains(t, dot, "  \"a\" [label=\"a\\nEventHub\"];\n")
+	assert.Contains(t, dot, "  \"a\" -> \"b\" [label=\"a to b\"];\n")
+	assert.Contains(t, dot, "  \"ghost\" [label=\"ghost\\n(missing)\", style=dashed, color=red];\n")
+	assert.Contains(t, dot, "  \"b\" -> \"ghost\" [label=\"b to ghost\"];\n")
+	assert.True(t, strings.HasSuffix(dot, "}\n"))
+}
+
+func TestToDOTRanked(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"a", "c"},
+		[2]string{"b", "d"},
+		[2]string{"c", "d"},
+	)
+
+	dot, err := dag.ToDOTRanked()
+	assert.NoError(t, err)
+	assert.Contains(t, dot, "  { rank=same; \"a\"; }\n")
+	assert.Contains(t, dot, "  { rank=same; \"b\"; \"c\"; }\n")
+	assert.Contains(t, dot, "  { rank=same; \"d\"; }\n")
+	assert.Contains(t, dot, "  \"b\" -> \"d\" [label=\"b to d\"];\n")
+	assert.NotContains(t, dag.ToDot(), "rank=same")
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "d to a", From: "d", To: "a"})
+	_, err = dag.ToDOTRanked()
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package constellation
+
+import (
+	"strings"
+)
+
+// index -- lookup tables from IDs to positions in the Services and Relationships slices, see BuildIndex.
+type index struct {
+	serviceCount      int
+	relationshipCount int
+
+	services      positions
+	relationships positions
+	from          positions
+	to            positions
+}
+
+// positions -- the slice positions holding each key, both as given and folded to lower case.
+type positions struct {
+	exact  map[string][]int
+	folded map[string][]int
+}
+
+// BuildIndex -- Build the lookup tables used by the Find methods, turning each lookup from a scan of the
+// Services or Relationships into a map access. Call it again (or InvalidateIndex) after changing the
+// Services or Relationships directly, including through the pointers returned by the Find methods, whenever
+// an ID, From or To changes. The methods of Config which change them drop the index themselves.
+func (m *Config) BuildIndex() {
+	idx := &index{
+		serviceCount:      len(m.Services),
+		relationshipCount: len(m.Relationships),
+		services:          newPositions(),
+		relationships:     newPositions(),
+		from:              newPositions(),
+		to:                newPositions(),
+	}
+
+	for i, val := range m.Services {
+		idx.services.add(val.ID, i)
+	}
+	for i, val := range m.Relationships {
+		idx.relationships.add(val.ID, i)
+		idx.from.add(val.From, i)
+		idx.to.add(val.To, i)
+	}
+
+	m.index = idx
+}
+
+// InvalidateIndex -- Drop the lookup tables, the Find methods fall back to scanning until BuildIndex is called.
+func (m *Config) InvalidateIndex() {
+	m.index = nil
+}
+
+// lookup returns the index, or nil if there is none or the slices have visibly changed since it was built.
+func (m *Config) lookup() *index {
+	if m.index == nil || m.index.serviceCount != len(m.Services) || m.index.relationshipCount != len(m.Relationships) {
+		return nil
+	}
+	return m.index
+}
+
+// lendService returns a pointer to the Service at position i, see FindService.
+func (m *Config) lendService(i int) *Service {
+	return &m.Services[i]
+}
+
+// lendRelationship returns a pointer to the Relationship at position i, see FindRelationship.
+func (m *Config) lendRelationship(i int) *Relationship {
+	return &m.Relationships[i]
+}
+
+func newPositions() positions {
+	return positions{exact: make(map[string][]int), folded: make(map[string][]int)}
+}
+
+func (p positions) add(key string, i int) {
+	p.exact[key] = append(p.exact[key], i)
+	p.folded[strings.ToLower(key)] = append(p.folded[strings.ToLower(key)], i)
+}
+
+// get returns the positions, in declaration order, matching the key exactly or, if caseInsensitive, in any case.
+func (p positions) get(key string, caseInsensitive bool) []int {
+	if caseInsensitive {
+		return p.folded[strings.ToLower(key)]
+	}
+	return p.exact[key]
+}
@@ -0,0 +1,110 @@
+package constellation_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/microsoft/abstrakt/internal/platform/constellation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexedFindingMatchesScan(t *testing.T) {
+	scanned := new(constellation.Config)
+	_ = scanned.LoadFile("testdata/valid.yaml")
+	scanned.Services = append(scanned.Services, constellation.Service{ID: "azure event hub", Type: "Duplicate"})
+
+	indexed := new(constellation.Config)
+	_ = indexed.LoadFile("testdata/valid.yaml")
+	indexed.Services = append(indexed.Services, constellation.Service{ID: "azure event hub", Type: "Duplicate"})
+	indexed.BuildIndex()
+
+	services := []string{"Azure Event Hub", "azure event hub", "AZURE EVENT HUB", "Event Logger", "Missing"}
+	relationships := []string{"Generator to Event Hubs Link", "generator TO event hubs link", "Missing"}
+
+	for _, matching := range []constellation.CaseMatching{constellation.AnyCase, constellation.ExactCase} {
+		scanned.CaseMatching = matching
+		indexed.CaseMatching = matching
+
+		for _, i := range services {
+			assert.Equal(t, scanned.FindService(i), indexed.FindService(i), "FindService(%q)", i)
+
+			scannedService, scannedErr := scanned.Resolve(i)
+			indexedService, indexedErr := indexed.Resolve(i)
+			assert.Equal(t, scannedService, indexedService, "Resolve(%q)", i)
+			assert.Equal(t, scannedErr, indexedErr, "Resolve(%q)", i)
+
+			assert.Equal(t, scanned.FindRelationshipByToName(i), indexed.FindRelationshipByToName(i), "FindRelationshipByToName(%q)", i)
+			assert.Equal(t, scanned.FindRelationshipByFromName(i), indexed.FindRelationshipByFromName(i), "FindRelationshipByFromName(%q)", i)
+		}
+
+		for _, i := range relationships {
+			assert.Equal(t, scanned.FindRelationship(i), indexed.FindRelationship(i), "FindRelationship(%q)", i)
+		}
+	}
+}
+
+func TestIndexInvalidation(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"}, [2]string{"a", "b"})
+	dag.BuildIndex()
+
+	dag.Services = append(dag.Services, constellation.Service{ID: "d", Type: "Test"})
+	assert.NotNil(t, dag.FindService("d"), "a stale index should be ignored")
+
+	dag.Services[0].ID = "renamed"
+	dag.BuildIndex()
+	assert.Nil(t, dag.FindService("a"))
+	assert.NotNil(t, dag.FindService("renamed"))
+
+	_, err := dag.CollapseSubgraph([]string{"b", "c"}, "bc", "Test")
+	assert.NoError(t, err)
+	assert.NotNil(t, dag.FindService("bc"))
+	assert.Nil(t, dag.FindService("b"))
+
+	dag.BuildIndex()
+	dag.InvalidateIndex()
+	dag.Services[0].ID = "a"
+	assert.NotNil(t, dag.FindService("a"))
+}
+
+func TestIndexRenameThroughFind(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"}, [2]string{"a", "b"}, [2]string{"b", "c"})
+	dag.BuildIndex()
+
+	dag.FindService("a").ID = "z"
+	dag.BuildIndex()
+	assert.Nil(t, dag.FindService("a"))
+	if assert.NotNil(t, dag.FindService("z")) {
+		assert.Equal(t, "z", dag.FindService("z").ID)
+	}
+
+	dag.FindRelationship("b to c").From = "z"
+	dag.InvalidateIndex()
+	assert.Len(t, dag.FindRelationshipByFromName("z"), 1)
+	assert.Empty(t, dag.FindRelationshipByFromName("b"))
+}
+
+func BenchmarkFindService(b *testing.B) {
+	for _, size := range []int{100, 10000} {
+		services := make([]string, size)
+		for i := range services {
+			services[i] = fmt.Sprintf("service-%v", i)
+		}
+		dag := buildConfig(services)
+
+		b.Run(fmt.Sprintf("Scan%v", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				dag.FindService(services[i%size])
+			}
+		})
+
+		b.Run(fmt.Sprintf("Indexed%v", size), func(b *testing.B) {
+			dag.BuildIndex()
+			defer dag.InvalidateIndex()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				dag.FindService(services[i%size])
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package constellation
+
+import (
+	"math"
+)
+
+// PropertyString -- the named Property as a string, false if it is missing or not a string.
+func (s *Service) PropertyString(key string) (string, bool) {
+	return propertyString(s.Properties, key)
+}
+
+// PropertyInt -- the named Property as an int, false if it is missing or not a whole number.
+func (s *Service) PropertyInt(key string) (int, bool) {
+	return propertyInt(s.Properties, key)
+}
+
+// PropertyBool -- the named Property as a bool, false if it is missing or not a bool.
+func (s *Service) PropertyBool(key string) (bool, bool) {
+	return propertyBool(s.Properties, key)
+}
+
+// PropertyString -- the named Property as a string, false if it is missing or not a string.
+func (r *Relationship) PropertyString(key string) (string, bool) {
+	return propertyString(r.Properties, key)
+}
+
+// PropertyInt -- the named Property as an int, false if it is missing or not a whole number.
+func (r *Relationship) PropertyInt(key string) (int, bool) {
+	return propertyInt(r.Properties, key)
+}
+
+// PropertyBool -- the named Property as a bool, false if it is missing or not a bool.
+func (r *Relationship) PropertyBool(key string) (bool, bool) {
+	return propertyBool(r.Properties, key)
+}
+
+func propertyString(properties map[string]Property, key string) (string, bool) {
+	value, ok := properties[key].(string)
+	return value, ok
+}
+
+// propertyInt accepts any of the numeric types yaml may decode into, as long as the value is whole and fits in
+// an int.
+func propertyInt(properties map[string]Property, key string) (int, bool) {
+	switch v := properties[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		if int64(int(v)) == v {
+			return int(v), true
+		}
+	case uint64:
+		if v <= math.MaxInt64 && uint64(int(v)) == v {
+			return int(v), true
+		}
+	case float64:
+		// float64(math.MaxInt64) rounds up to 2^63, which is out of range
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 && int64(int(v)) == int64(v) {
+			return int(v), true
+		}
+	}
+
+	return 0, false
+}
+
+func propertyBool(properties map[string]Property, key string) (bool, bool) {
+	value, ok := properties[key].(bool)
+	return value, ok
+}
+
+// toFloat converts the numeric types yaml may decode Property values into to a float64.
+func toFloat(value Property) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,98 @@
+package constellation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/microsoft/abstrakt/internal/platform/constellation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceProperties(t *testing.T) {
+	dag := &constellation.Config{}
+	err := dag.LoadString("Name: Test\nId: test\nServices:\n- Id: a\n  Type: Test\n  Properties:\n" +
+		"    name: frontend\n    replicas: 3\n    scale: 2.0\n    ratio: 0.5\n    public: true\n")
+	assert.NoError(t, err)
+	service := &dag.Services[0]
+
+	name, ok := service.PropertyString("name")
+	assert.True(t, ok)
+	assert.Equal(t, "frontend", name)
+
+	replicas, ok := service.PropertyInt("replicas")
+	assert.True(t, ok)
+	assert.Equal(t, 3, replicas)
+
+	scale, ok := service.PropertyInt("scale")
+	assert.True(t, ok)
+	assert.Equal(t, 2, scale)
+
+	public, ok := service.PropertyBool("public")
+	assert.True(t, ok)
+	assert.True(t, public)
+
+	_, ok = service.PropertyInt("ratio")
+	assert.False(t, ok, "A fraction is not an int")
+
+	_, ok = service.PropertyInt("name")
+	assert.False(t, ok, "A string is not an int")
+
+	_, ok = service.PropertyString("replicas")
+	assert.False(t, ok, "An int is not a string")
+
+	_, ok = service.PropertyBool("missing")
+	assert.False(t, ok)
+}
+
+func TestPropertyIntRange(t *testing.T) {
+	service := &constellation.Service{ID: "a", Properties: map[string]constellation.Property{
+		"inf":      math.Inf(1),
+		"negInf":   math.Inf(-1),
+		"nan":      math.NaN(),
+		"huge":     1e300,
+		"edge":     float64(math.MaxInt64),
+		"unsigned": uint64(math.MaxUint64),
+		"big":      int64(1) << 40,
+		"negative": -1e15,
+	}}
+
+	for _, i := range []string{"inf", "negInf", "nan", "huge", "edge", "unsigned"} {
+		value, ok := service.PropertyInt(i)
+		assert.False(t, ok, "%v is out of the int range", i)
+		assert.Equal(t, 0, value)
+	}
+
+	big, ok := service.PropertyInt("big")
+	assert.True(t, ok)
+	assert.Equal(t, 1<<40, big)
+
+	negative, ok := service.PropertyInt("negative")
+	assert.True(t, ok)
+	assert.Equal(t, -1000000000000000, negative)
+}
+
+func TestRelationshipProperties(t *testing.T) {
+	relationship := &constellation.Relationship{ID: "a to b", Properties: map[string]constellation.Property{
+		"protocol": "http",
+		"port":     float64(8080),
+		"secure":   false,
+	}}
+
+	protocol, ok := relationship.PropertyString("protocol")
+	assert.True(t, ok)
+	assert.Equal(t, "http", protocol)
+
+	port, ok := relationship.PropertyInt("port")
+	assert.True(t, ok)
+	assert.Equal(t, 8080, port)
+
+	secure, ok := relationship.PropertyBool("secure")
+	assert.True(t, ok)
+	assert.False(t, secure)
+
+	_, ok = relationship.PropertyBool("protocol")
+	assert.False(t, ok)
+
+	_, ok = (&constellation.Relationship{}).PropertyString("missing")
+	assert.False(t, ok, "A nil Properties map should be safe")
+}
@@ -0,0 +1,89 @@
+package constellation
+
+import (
+	"sync"
+)
+
+// SafeConfig -- a Config guarded by a sync.RWMutex, for sharing between goroutines. A bare Config is only safe
+// for concurrent reads: changing it, including through BuildIndex, while other goroutines read it is a data race.
+// The Find methods return copies, as pointers into the Config would outlive the lock.
+type SafeConfig struct {
+	mu     sync.RWMutex
+	config *Config
+}
+
+// NewSafeConfig -- wrap the Config, which must not be used directly afterwards.
+func NewSafeConfig(m *Config) *SafeConfig {
+	return &SafeConfig{config: m}
+}
+
+// Read runs fn with the Config locked for reading. fn must not change the Config or keep references into it.
+func (s *SafeConfig) Read(fn func(m *Config)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.config)
+}
+
+// Write runs fn with the Config locked for writing, returning its error.
+func (s *SafeConfig) Write(fn func(m *Config) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.config)
+}
+
+// FindService -- a copy of the Service with the given id, see Config.FindService.
+func (s *SafeConfig) FindService(serviceID string) *Service {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if found := s.config.FindService(serviceID); found != nil {
+		val := *found
+		return &val
+	}
+	return nil
+}
+
+// FindRelationship -- a copy of the Relationship with the given id, see Config.FindRelationship.
+func (s *SafeConfig) FindRelationship(relationshipID string) *Relationship {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if found := s.config.FindRelationship(relationshipID); found != nil {
+		val := *found
+		return &val
+	}
+	return nil
+}
+
+// FindRelationshipByToName -- see Config.FindRelationshipByToName.
+func (s *SafeConfig) FindRelationshipByToName(relationshipToName string) []Relationship {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.FindRelationshipByToName(relationshipToName)
+}
+
+// FindRelationshipByFromName -- see Config.FindRelationshipByFromName.
+func (s *SafeConfig) FindRelationshipByFromName(relationshipFromName string) []Relationship {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.FindRelationshipByFromName(relationshipFromName)
+}
+
+// AddService -- see Config.AddService.
+func (s *SafeConfig) AddService(service Service) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.AddService(service)
+}
+
+// RemoveService -- see Config.RemoveService.
+func (s *SafeConfig) RemoveService(serviceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.RemoveService(serviceID)
+}
+
+// BuildIndex -- see Config.BuildIndex.
+func (s *SafeConfig) BuildIndex() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config.BuildIndex()
+}
@@ -0,0 +1,57 @@
+package constellation_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/microsoft/abstrakt/internal/platform/constellation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeConfigConcurrentAccess(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	safe := constellation.NewSafeConfig(dag)
+	safe.BuildIndex()
+
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				assert.NotNil(t, safe.FindService("a"))
+				assert.NotNil(t, safe.FindRelationship("a to b"))
+				safe.FindService(fmt.Sprintf("added-%v", i))
+				safe.FindRelationshipByFromName("a")
+				safe.Read(func(m *constellation.Config) {
+					_ = m.Neighbors("a")
+				})
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			id := fmt.Sprintf("added-%v", i)
+			assert.NoError(t, safe.AddService(constellation.Service{ID: id, Type: "Test"}))
+			safe.BuildIndex()
+			if i%2 == 0 {
+				assert.NoError(t, safe.RemoveService(id))
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	safe.Read(func(m *constellation.Config) {
+		assert.Len(t, m.Services, 102)
+	})
+
+	err := safe.Write(func(m *constellation.Config) error {
+		return m.ValidateModel()
+	})
+	assert.NoError(t, err)
+}
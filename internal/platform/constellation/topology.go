@@ -0,0 +1,1261 @@
+package constellation
+
+import (
+	"container/heap"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/microsoft/abstrakt/tools/find"
+)
+
+// GraphStats -- summary statistics of the graph formed by a constellation
+type GraphStats struct {
+	Services      int
+	Relationships int
+	MaxInDegree   int
+	MaxOutDegree  int
+	AverageDegree float64
+	Components    int
+	Acyclic       bool
+}
+
+// CycleError -- returned when an operation requires an acyclic constellation
+type CycleError struct {
+	// Services holds the sorted IDs of the Services which could not be ordered.
+	Services []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("Constellation contains a cycle between Services %v", e.Services)
+}
+
+// IntIDMap assigns a dense integer (0..n-1) to every Service, in sorted ID order.
+// Returns the ID to index lookup as well as the reverse (index to ID) slice.
+func (m *Config) IntIDMap() (map[string]int, []string) {
+	IDs := m.serviceIDs()
+	lookup := make(map[string]int, len(IDs))
+
+	for i, id := range IDs {
+		lookup[id] = i
+	}
+
+	return lookup, IDs
+}
+
+// StructurallyEqual checks if two constellations have the same shape. Services are compared by ID and Type,
+// Relationships by their From/To endpoints. The constellation ID and the Relationship IDs are ignored.
+func (m *Config) StructurallyEqual(other *Config) bool {
+	services, relationships := m.shape()
+	otherServices, otherRelationships := other.shape()
+
+	return reflect.DeepEqual(services, otherServices) && reflect.DeepEqual(relationships, otherRelationships)
+}
+
+// DetectCycles searches the constellation depth first for cycles, treating Services as nodes and Relationships
+// as edges From -> To. Every cycle found is returned as the ordered IDs of its Services, a Service with a
+// Relationship to itself is a cycle of one. Returns an error if a Relationship references an undeclared Service.
+func (m *Config) DetectCycles() ([][]string, error) {
+	if missing := m.ServiceExists(); len(missing) > 0 {
+		var IDs []string
+		for id := range missing {
+			IDs = append(IDs, id)
+		}
+		sort.Strings(IDs)
+		return nil, fmt.Errorf("Relationships %v reference undeclared services", IDs)
+	}
+
+	adjacency := m.adjacency()
+	cycles := [][]string{}
+	done := make(map[string]bool)
+	onStack := make(map[string]int)
+	var stack []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		onStack[id] = len(stack)
+		stack = append(stack, id)
+
+		for _, i := range adjacency[id] {
+			if start, exists := onStack[i]; exists {
+				cycles = append(cycles, append([]string{}, stack[start:]...))
+			} else if !done[i] {
+				visit(i)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		delete(onStack, id)
+		done[id] = true
+	}
+
+	for _, id := range sortedKeys(adjacency) {
+		if !done[id] {
+			visit(id)
+		}
+	}
+
+	return cycles, nil
+}
+
+// FeedbackEdgeSet returns a set of Relationships whose removal leaves the constellation acyclic: the back edges
+// of a depth first search, which is small but not guaranteed minimal. Returns nil for an acyclic constellation.
+func (m *Config) FeedbackEdgeSet() (feedback []*Relationship) {
+	adjacency := m.adjacency()
+	outgoing := make(map[string][]int)
+	for i, v := range m.Relationships {
+		_, fromExists := adjacency[v.From]
+		_, toExists := adjacency[v.To]
+		if fromExists && toExists {
+			outgoing[v.From] = append(outgoing[v.From], i)
+		}
+	}
+
+	done := make(map[string]bool)
+	onStack := make(map[string]bool)
+
+	var visit func(id string)
+	visit = func(id string) {
+		onStack[id] = true
+
+		for _, i := range outgoing[id] {
+			if to := m.Relationships[i].To; onStack[to] {
+				feedback = append(feedback, &m.Relationships[i])
+			} else if !done[to] {
+				visit(to)
+			}
+		}
+
+		onStack[id] = false
+		done[id] = true
+	}
+
+	for _, id := range sortedKeys(adjacency) {
+		if !done[id] {
+			visit(id)
+		}
+	}
+
+	return
+}
+
+// TopologicalSort orders the Services so that every Service comes after the Services it depends on (the From of
+// its incoming Relationships), using Kahn's algorithm. Returns a *CycleError if the constellation contains a cycle.
+func (m *Config) TopologicalSort() (order []string, err error) {
+	adjacency := m.adjacency()
+	inDegree := make(map[string]int)
+	for _, to := range adjacency {
+		for _, i := range to {
+			inDegree[i]++
+		}
+	}
+
+	var queue []string
+	for _, i := range m.Services {
+		if _, exists := find.Slice(queue, i.ID); !exists && inDegree[i.ID] == 0 {
+			queue = append(queue, i.ID)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+
+		for _, i := range adjacency[id] {
+			inDegree[i]--
+			if inDegree[i] == 0 {
+				queue = append(queue, i)
+			}
+		}
+	}
+
+	if len(order) != len(adjacency) {
+		return nil, &CycleError{Services: m.unsorted(order)}
+	}
+
+	return
+}
+
+// StableTopologicalSort orders the Services so that every Service comes after the Services it depends on (the
+// From of its incoming Relationships). Services which are ready at the same time are ordered by ID, so the result
+// is deterministic. Returns a *CycleError if the constellation contains a cycle.
+func (m *Config) StableTopologicalSort() (order []string, err error) {
+	adjacency := m.adjacency()
+	inDegree := make(map[string]int)
+
+	for _, to := range adjacency {
+		for _, i := range to {
+			inDegree[i]++
+		}
+	}
+
+	ready := &idHeap{}
+	for id := range adjacency {
+		if inDegree[id] == 0 {
+			heap.Push(ready, id)
+		}
+	}
+
+	for ready.Len() > 0 {
+		id := heap.Pop(ready).(string)
+		order = append(order, id)
+
+		for _, i := range adjacency[id] {
+			inDegree[i]--
+			if inDegree[i] == 0 {
+				heap.Push(ready, i)
+			}
+		}
+	}
+
+	if len(order) != len(adjacency) {
+		return nil, &CycleError{Services: m.unsorted(order)}
+	}
+
+	return
+}
+
+// DeployBlockers returns the critical upstream path of a Service: the longest chain of Services which must be
+// deployed, in order, before it. Returns an error if the Service does not exist or the constellation is cyclic.
+func (m *Config) DeployBlockers(serviceID string) (blockers []string, err error) {
+	adjacency := m.adjacency()
+	if _, exists := adjacency[serviceID]; !exists {
+		return nil, fmt.Errorf("Service '%v' not found", serviceID)
+	}
+
+	order, err := m.StableTopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	depth := make(map[string]int)
+	previous := make(map[string]string)
+
+	for _, id := range order {
+		for _, i := range adjacency[id] {
+			if depth[id]+1 > depth[i] {
+				depth[i] = depth[id] + 1
+				previous[i] = id
+			}
+		}
+	}
+
+	for id := serviceID; depth[id] > 0; id = previous[id] {
+		blockers = append([]string{previous[id]}, blockers...)
+	}
+
+	return
+}
+
+// ReachabilityCoverage returns the fraction of Services which can be reached from a root Service, that is a
+// Service without incoming Relationships. Services only reachable through a cycle lower the coverage.
+func (m *Config) ReachabilityCoverage() float64 {
+	adjacency := m.adjacency()
+	if len(adjacency) == 0 {
+		return 0
+	}
+
+	reached := reach(adjacency, roots(adjacency))
+	return float64(len(reached)) / float64(len(adjacency))
+}
+
+// Stats computes summary statistics of the constellation graph from a single adjacency map: the degrees and the
+// components (by union-find) as the Relationships are counted, acyclicity by peeling off the Services without
+// incoming Relationships. Relationships referencing undeclared Services are not counted, so Relationships can
+// be less than len(m.Relationships).
+func (m *Config) Stats() (stats GraphStats) {
+	adjacency := m.adjacency()
+	inDegree := make(map[string]int)
+
+	parent := make(map[string]string)
+	var root func(id string) string
+	root = func(id string) string {
+		if parent[id] != id {
+			parent[id] = root(parent[id])
+		}
+		return parent[id]
+	}
+	for from := range adjacency {
+		parent[from] = from
+	}
+
+	stats.Services = len(adjacency)
+	stats.Components = stats.Services
+	for from, to := range adjacency {
+		stats.Relationships += len(to)
+		if len(to) > stats.MaxOutDegree {
+			stats.MaxOutDegree = len(to)
+		}
+		for _, i := range to {
+			inDegree[i]++
+			if a, b := root(from), root(i); a != b {
+				parent[a] = b
+				stats.Components--
+			}
+		}
+	}
+
+	var ready []string
+	for from := range adjacency {
+		if inDegree[from] > stats.MaxInDegree {
+			stats.MaxInDegree = inDegree[from]
+		}
+		if inDegree[from] == 0 {
+			ready = append(ready, from)
+		}
+	}
+
+	if stats.Services > 0 {
+		stats.AverageDegree = float64(2*stats.Relationships) / float64(stats.Services)
+	}
+
+	peeled := 0
+	for len(ready) > 0 {
+		from := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+		peeled++
+		for _, i := range adjacency[from] {
+			if inDegree[i]--; inDegree[i] == 0 {
+				ready = append(ready, i)
+			}
+		}
+	}
+	stats.Acyclic = peeled == stats.Services
+
+	return
+}
+
+// Bridges returns the Relationships whose removal would split their weakly connected component in two, i.e. the
+// single points of failure between groups of Services, in declaration order. Parallel Relationships are never
+// bridges, as either one keeps the Services connected.
+func (m *Config) Bridges() (bridges []*Relationship) {
+	adjacency := m.adjacency()
+	type edge struct{ to, relationship int }
+	IDs := sortedKeys(adjacency)
+	undirected := make([][]edge, len(IDs))
+	position := make(map[string]int, len(IDs))
+	for n, id := range IDs {
+		position[id] = n
+	}
+
+	for i, v := range m.Relationships {
+		from, fromExists := position[v.From]
+		to, toExists := position[v.To]
+		if fromExists && toExists && from != to {
+			undirected[from] = append(undirected[from], edge{to, i})
+			undirected[to] = append(undirected[to], edge{from, i})
+		}
+	}
+
+	// discovered is the depth first discovery time of each Service, low the earliest time reachable from its subtree
+	discovered := make(map[int]int)
+	low := make(map[int]int)
+	var cut []int
+
+	var visit func(id int, via int)
+	visit = func(id int, via int) {
+		discovered[id] = len(discovered) + 1
+		low[id] = discovered[id]
+
+		for _, e := range undirected[id] {
+			if e.relationship == via {
+				continue
+			}
+			if _, seen := discovered[e.to]; seen {
+				if discovered[e.to] < low[id] {
+					low[id] = discovered[e.to]
+				}
+				continue
+			}
+
+			visit(e.to, e.relationship)
+			if low[e.to] < low[id] {
+				low[id] = low[e.to]
+			}
+			if low[e.to] > discovered[id] {
+				cut = append(cut, e.relationship)
+			}
+		}
+	}
+
+	for n := range IDs {
+		if _, seen := discovered[n]; !seen {
+			visit(n, -1)
+		}
+	}
+
+	sort.Ints(cut)
+	for _, i := range cut {
+		bridges = append(bridges, &m.Relationships[i])
+	}
+
+	return
+}
+
+// IsSimpleGraph checks that no two Services are connected by more than one Relationship in the same direction.
+func (m *Config) IsSimpleGraph() bool {
+	seen := make(map[[2]string]bool)
+	for _, i := range m.Relationships {
+		edge := [2]string{i.From, i.To}
+		if seen[edge] {
+			return false
+		}
+		seen[edge] = true
+	}
+
+	return true
+}
+
+// OutDegreeDistribution maps each out-degree to the number of Services with that many outgoing Relationships.
+// Relationships referencing undeclared Services are not counted.
+func (m *Config) OutDegreeDistribution() map[int]int {
+	distribution := make(map[int]int)
+	for _, to := range m.adjacency() {
+		distribution[len(to)]++
+	}
+
+	return distribution
+}
+
+// LevelTypeGrid groups the Services by deployment level and then by Type. A Service's level is the length of the
+// longest chain of Services it depends on, so all Services of one level can be deployed in parallel once the
+// previous levels are done. Returns an error if the constellation is cyclic.
+func (m *Config) LevelTypeGrid() (map[int]map[string][]string, error) {
+	levels, err := m.levels()
+	if err != nil {
+		return nil, err
+	}
+
+	grid := make(map[int]map[string][]string)
+	for _, i := range m.Services {
+		level := levels[i.ID]
+		if grid[level] == nil {
+			grid[level] = make(map[string][]string)
+		}
+		grid[level][i.Type] = append(grid[level][i.Type], i.ID)
+	}
+
+	return grid, nil
+}
+
+// levels returns the deployment level of each Service, i.e. the length of the longest chain of Services leading
+// to it. Returns an error if the constellation is cyclic.
+func (m *Config) levels() (map[string]int, error) {
+	order, err := m.StableTopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	adjacency := m.adjacency()
+	levels := make(map[string]int)
+
+	for _, id := range order {
+		for _, i := range adjacency[id] {
+			if levels[id]+1 > levels[i] {
+				levels[i] = levels[id] + 1
+			}
+		}
+	}
+
+	return levels, nil
+}
+
+// SliceByDepth returns a new constellation holding the Services whose deployment level (see LevelTypeGrid) lies
+// within [minDepth, maxDepth] and the Relationships between them. Returns an error if the constellation is cyclic.
+func (m *Config) SliceByDepth(minDepth int, maxDepth int) (*Config, error) {
+	levels, err := m.levels()
+	if err != nil {
+		return nil, err
+	}
+
+	band := make(map[string]bool)
+	for _, id := range m.serviceIDs() {
+		if levels[id] >= minDepth && levels[id] <= maxDepth {
+			band[id] = true
+		}
+	}
+
+	var relationships []Relationship
+	for _, i := range m.Relationships {
+		if band[i.From] && band[i.To] {
+			relationships = append(relationships, i)
+		}
+	}
+
+	return m.subset(band, relationships), nil
+}
+
+// WouldCreateCycle checks if adding a Relationship from -> to would introduce a cycle, i.e. if the from Service
+// can already be reached from the to Service. A Relationship from a Service to itself is always a cycle.
+func (m *Config) WouldCreateCycle(from string, to string) bool {
+	return reach(m.adjacency(), []string{to})[from]
+}
+
+// SuggestConnectingEdges returns a minimal set of From/To pairs which would join all weakly connected
+// components into one, chaining the first Service of each component to the first Service of the next.
+// Edges between separate components can never introduce a cycle.
+func (m *Config) SuggestConnectingEdges() (edges [][2]string) {
+	groups := components(m.adjacency())
+
+	for i := 1; i < len(groups); i++ {
+		edges = append(edges, [2]string{groups[i-1][0], groups[i][0]})
+	}
+
+	return
+}
+
+// Ancestors returns the sorted IDs of all Services from which the given Service can be reached, excluding the
+// Service itself. Returns an error if the Service does not exist.
+func (m *Config) Ancestors(serviceID string) ([]string, error) {
+	adjacency := m.adjacency()
+	if _, exists := adjacency[serviceID]; !exists {
+		return nil, fmt.Errorf("Service '%v' not found", serviceID)
+	}
+
+	reached := reach(reverse(adjacency), []string{serviceID})
+	delete(reached, serviceID)
+
+	return sortedSet(reached), nil
+}
+
+// ExtractSubgraph returns a new constellation, named after this one, holding the Services reachable from the
+// given roots and the Relationships between them. Returns an error if a root does not exist.
+func (m *Config) ExtractSubgraph(roots []string) (*Config, error) {
+	adjacency := m.adjacency()
+	for _, i := range roots {
+		if _, exists := adjacency[i]; !exists {
+			return nil, fmt.Errorf("Service '%v' not found", i)
+		}
+	}
+
+	reached := reach(adjacency, roots)
+
+	var relationships []Relationship
+	for _, i := range m.Relationships {
+		if reached[i.From] && reached[i.To] {
+			relationships = append(relationships, i)
+		}
+	}
+
+	res := m.subset(reached, relationships)
+	res.Name = fmt.Sprintf("%v subgraph", m.Name)
+	return res, nil
+}
+
+// SpanningTree returns a new constellation holding the Services reachable from root and only the Relationships
+// through which a breadth first walk first reached each of them. Returns an error if root does not exist.
+func (m *Config) SpanningTree(root string) (*Config, error) {
+	if _, exists := m.adjacency()[root]; !exists {
+		return nil, fmt.Errorf("Service '%v' not found", root)
+	}
+
+	outgoing := m.outgoing()
+	visited := map[string]bool{root: true}
+	queue := []string{root}
+	var tree []Relationship
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, i := range outgoing[id] {
+			if !visited[i.To] {
+				visited[i.To] = true
+				tree = append(tree, i)
+				queue = append(queue, i.To)
+			}
+		}
+	}
+
+	return m.subset(visited, tree), nil
+}
+
+// DependentsCutBy returns the sorted IDs of the Services which would no longer reach any leaf Service (a Service
+// without outgoing Relationships) if the given Service was removed, i.e. all their paths to a leaf pass through
+// it. Returns an error if the Service does not exist.
+func (m *Config) DependentsCutBy(serviceID string) (cut []string, err error) {
+	adjacency := m.adjacency()
+	if _, exists := adjacency[serviceID]; !exists {
+		return nil, fmt.Errorf("Service '%v' not found", serviceID)
+	}
+
+	pruned := without(adjacency, serviceID)
+
+	leaves := make(map[string]bool)
+	for id, to := range adjacency {
+		if len(to) == 0 {
+			leaves[id] = true
+		}
+	}
+
+	reachesLeaf := func(adjacency map[string][]string, start string) bool {
+		for i := range reach(adjacency, []string{start}) {
+			if leaves[i] {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, id := range sortedKeys(pruned) {
+		if reachesLeaf(adjacency, id) && !reachesLeaf(pruned, id) {
+			cut = append(cut, id)
+		}
+	}
+
+	return
+}
+
+// EdgeBetweenness counts, for every Relationship, the (fractional) number of shortest paths between pairs of
+// Services passing through it. Relationships with a high score are critical links. The keys point into the
+// Relationships slice of the constellation.
+func (m *Config) EdgeBetweenness() map[*Relationship]float64 {
+	adjacency := m.adjacency()
+	scores := make(map[*Relationship]float64)
+	outgoing := make(map[string][]*Relationship)
+
+	for n := range m.Relationships {
+		i := &m.Relationships[n]
+		scores[i] = 0
+
+		_, fromExists := adjacency[i.From]
+		_, toExists := adjacency[i.To]
+		if fromExists && toExists {
+			outgoing[i.From] = append(outgoing[i.From], i)
+		}
+	}
+
+	type predecessor struct {
+		service      string
+		relationship *Relationship
+	}
+
+	for _, source := range sortedKeys(adjacency) {
+		distance := map[string]int{source: 0}
+		paths := map[string]float64{source: 1}
+		predecessors := make(map[string][]predecessor)
+		visited := []string{}
+		queue := []string{source}
+
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			visited = append(visited, id)
+
+			for _, i := range outgoing[id] {
+				if _, seen := distance[i.To]; !seen {
+					distance[i.To] = distance[id] + 1
+					queue = append(queue, i.To)
+				}
+				if distance[i.To] == distance[id]+1 {
+					paths[i.To] += paths[id]
+					predecessors[i.To] = append(predecessors[i.To], predecessor{id, i})
+				}
+			}
+		}
+
+		dependency := make(map[string]float64)
+		for n := len(visited) - 1; n >= 0; n-- {
+			id := visited[n]
+			for _, p := range predecessors[id] {
+				share := paths[p.service] / paths[id] * (1 + dependency[id])
+				scores[p.relationship] += share
+				dependency[p.service] += share
+			}
+		}
+	}
+
+	return scores
+}
+
+// ImpactCounts returns the number of Services the given Service transitively depends on (upstream) and the
+// number of Services transitively depending on it (downstream). Returns an error if the Service does not exist.
+func (m *Config) ImpactCounts(serviceID string) (upstream int, downstream int, err error) {
+	ancestors, err := m.Ancestors(serviceID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	descendants := reach(m.adjacency(), []string{serviceID})
+	delete(descendants, serviceID)
+
+	return len(ancestors), len(descendants), nil
+}
+
+// Reachable returns the IDs of the Services reachable from start by following Relationships, walking breadth
+// first: nearer Services come first, Services at the same distance in sorted order. start itself is excluded,
+// even if a cycle leads back to it. Returns an error if start does not exist.
+func (m *Config) Reachable(start string) ([]string, error) {
+	adjacency := m.adjacency()
+	if _, exists := adjacency[start]; !exists {
+		return nil, fmt.Errorf("Service '%v' not found", start)
+	}
+
+	distance, _ := bfs(adjacency, start)
+	delete(distance, start)
+
+	reached := make([]string, 0, len(distance))
+	for id := range distance {
+		reached = append(reached, id)
+	}
+	sort.Slice(reached, func(i, j int) bool {
+		if distance[reached[i]] != distance[reached[j]] {
+			return distance[reached[i]] < distance[reached[j]]
+		}
+		return reached[i] < reached[j]
+	})
+
+	return reached, nil
+}
+
+// ShortestPath returns the IDs of the Services along a shortest chain of Relationships from -> to, including both
+// ends, or just from when from and to are the same Service. Returns nil without an error if there's no such
+// chain, and an error if either Service does not exist.
+func (m *Config) ShortestPath(from string, to string) ([]string, error) {
+	adjacency := m.adjacency()
+	for _, i := range []string{from, to} {
+		if _, exists := adjacency[i]; !exists {
+			return nil, fmt.Errorf("Service '%v' not found", i)
+		}
+	}
+
+	distance, parent := bfs(adjacency, from)
+	if _, reached := distance[to]; !reached {
+		return nil, nil
+	}
+
+	return trace(parent, from, to), nil
+}
+
+// LongestSharedSubpath returns the longest run of consecutive Services common to both paths, e.g. as returned
+// by ShortestPath. Of several equally long runs the one found first in a is returned, nil if none is shared.
+func (m *Config) LongestSharedSubpath(a []string, b []string) []string {
+	// lengths[j] is the length of the shared run ending at a[i] and b[j]
+	lengths := make([]int, len(b)+1)
+	best, end := 0, 0
+
+	for i := range a {
+		for j := len(b); j > 0; j-- {
+			if a[i] == b[j-1] {
+				lengths[j] = lengths[j-1] + 1
+				if lengths[j] > best {
+					best, end = lengths[j], i+1
+				}
+			} else {
+				lengths[j] = 0
+			}
+		}
+	}
+
+	if best == 0 {
+		return nil
+	}
+	return append([]string{}, a[end-best:end]...)
+}
+
+// ChangeImpact returns the sorted IDs of the Services transitively downstream of the given Service, together
+// with the Relationships connecting the Service and its downstream Services, in declaration order. Returns an
+// error if the Service does not exist.
+func (m *Config) ChangeImpact(serviceID string) (services []string, relationships []*Relationship, err error) {
+	adjacency := m.adjacency()
+	if _, exists := adjacency[serviceID]; !exists {
+		return nil, nil, fmt.Errorf("Service '%v' not found", serviceID)
+	}
+
+	reached := reach(adjacency, []string{serviceID})
+	for i := range m.Relationships {
+		if reached[m.Relationships[i].From] && reached[m.Relationships[i].To] {
+			relationships = append(relationships, &m.Relationships[i])
+		}
+	}
+
+	delete(reached, serviceID)
+
+	return sortedSet(reached), relationships, nil
+}
+
+// MandatoryIntermediates returns the sorted IDs of the Services, other than from and to, which lie on every path
+// from -> to. Returns an error if either Service does not exist or to can't be reached from from.
+func (m *Config) MandatoryIntermediates(from string, to string) (mandatory []string, err error) {
+	adjacency := m.adjacency()
+	for _, i := range []string{from, to} {
+		if _, exists := adjacency[i]; !exists {
+			return nil, fmt.Errorf("Service '%v' not found", i)
+		}
+	}
+
+	reachable := reach(adjacency, []string{from})
+	if !reachable[to] {
+		return nil, fmt.Errorf("Service '%v' can't be reached from '%v'", to, from)
+	}
+
+	reaching := reach(reverse(adjacency), []string{to})
+	for _, id := range sortedSet(reachable) {
+		if id == from || id == to || !reaching[id] {
+			continue
+		}
+		if !reach(without(adjacency, id), []string{from})[to] {
+			mandatory = append(mandatory, id)
+		}
+	}
+
+	return
+}
+
+// FindDescriptionCycles groups Relationships by Description and, for every Description whose Relationships alone
+// form a cycle, returns the sorted IDs of the Services on such cycles. Descriptions label the kind of
+// Relationship, e.g. "replicates-to".
+func (m *Config) FindDescriptionCycles() map[string][]string {
+	byDescription := make(map[string]map[string][]string)
+	services := m.adjacency()
+
+	for _, i := range m.Relationships {
+		_, fromExists := services[i.From]
+		_, toExists := services[i.To]
+		if !fromExists || !toExists {
+			continue
+		}
+
+		if byDescription[i.Description] == nil {
+			byDescription[i.Description] = make(map[string][]string)
+		}
+		byDescription[i.Description][i.From] = append(byDescription[i.Description][i.From], i.To)
+	}
+
+	cycles := make(map[string][]string)
+	for description, adjacency := range byDescription {
+		if onCycle := cyclic(adjacency); len(onCycle) > 0 {
+			cycles[description] = onCycle
+		}
+	}
+
+	return cycles
+}
+
+// TypeEdgeCounts counts, per Service Type, the Relationships touching at least one Service of that Type.
+// A Relationship between two Services of the same Type is counted once.
+func (m *Config) TypeEdgeCounts() map[string]int {
+	counts := make(map[string]int)
+
+	for _, i := range m.Relationships {
+		types := make(map[string]bool)
+		for _, j := range []string{i.From, i.To} {
+			if service := m.FindService(j); service != nil {
+				types[service.Type] = true
+			}
+		}
+
+		for t := range types {
+			counts[t]++
+		}
+	}
+
+	return counts
+}
+
+// BatchSchedule splits the deployment into batches of at most maxParallel Services. Every Service is in a later
+// batch than all the Services it depends on. Ready Services are picked in ID order. Returns an error if
+// maxParallel is less than one or the constellation is cyclic.
+func (m *Config) BatchSchedule(maxParallel int) (batches [][]string, err error) {
+	if maxParallel < 1 {
+		return nil, fmt.Errorf("Batch size must be at least 1, got %v", maxParallel)
+	}
+
+	adjacency := m.adjacency()
+	inDegree := make(map[string]int)
+	for _, to := range adjacency {
+		for _, i := range to {
+			inDegree[i]++
+		}
+	}
+
+	ready := &idHeap{}
+	for id := range adjacency {
+		if inDegree[id] == 0 {
+			heap.Push(ready, id)
+		}
+	}
+
+	var scheduled []string
+	for ready.Len() > 0 {
+		var batch []string
+		for ready.Len() > 0 && len(batch) < maxParallel {
+			batch = append(batch, heap.Pop(ready).(string))
+		}
+
+		for _, id := range batch {
+			for _, i := range adjacency[id] {
+				inDegree[i]--
+				if inDegree[i] == 0 {
+					heap.Push(ready, i)
+				}
+			}
+		}
+
+		scheduled = append(scheduled, batch...)
+		batches = append(batches, batch)
+	}
+
+	if len(scheduled) != len(adjacency) {
+		return nil, &CycleError{Services: m.unsorted(scheduled)}
+	}
+
+	return
+}
+
+// Frontier returns the sorted IDs of the Services, not yet visited, with an incoming Relationship from a visited
+// Service, i.e. the next Services to roll out to.
+func (m *Config) Frontier(visited []string) []string {
+	adjacency := m.adjacency()
+	done := make(map[string]bool)
+	for _, i := range visited {
+		done[i] = true
+	}
+
+	next := make(map[string]bool)
+	for _, i := range visited {
+		for _, j := range adjacency[i] {
+			if !done[j] {
+				next[j] = true
+			}
+		}
+	}
+
+	return sortedSet(next)
+}
+
+// ShortestCycleThrough returns the shortest cycle containing the given Service, as the ordered Service IDs
+// starting with the Service itself. Returns an error if the Service does not exist or lies on no cycle.
+func (m *Config) ShortestCycleThrough(serviceID string) ([]string, error) {
+	adjacency := m.adjacency()
+	if _, exists := adjacency[serviceID]; !exists {
+		return nil, fmt.Errorf("Service '%v' not found", serviceID)
+	}
+
+	distance, parent := bfs(adjacency, serviceID)
+
+	predecessors := reverse(adjacency)[serviceID]
+	sort.Strings(predecessors)
+
+	last := ""
+	for _, i := range predecessors {
+		if _, reached := distance[i]; reached && (last == "" || distance[i] < distance[last]) {
+			last = i
+		}
+	}
+
+	if last == "" {
+		return nil, fmt.Errorf("Service '%v' is not part of a cycle", serviceID)
+	}
+
+	return trace(parent, serviceID, last), nil
+}
+
+// ComponentCount returns the number of weakly connected components, i.e. groups of Services connected to each
+// other ignoring the direction of Relationships.
+func (m *Config) ComponentCount() int {
+	return len(components(m.adjacency()))
+}
+
+// FindCycleOnlyServices returns the sorted IDs of the Services which can be reached from a root Service, but
+// only along paths using a Relationship which is part of a cycle.
+func (m *Config) FindCycleOnlyServices() []string {
+	adjacency := m.adjacency()
+
+	acyclic := make(map[string][]string)
+	for from, to := range adjacency {
+		acyclic[from] = nil
+		for _, i := range to {
+			if !reach(adjacency, []string{i})[from] {
+				acyclic[from] = append(acyclic[from], i)
+			}
+		}
+	}
+
+	start := roots(adjacency)
+	reached := reach(adjacency, start)
+	for i := range reach(acyclic, start) {
+		delete(reached, i)
+	}
+
+	return sortedSet(reached)
+}
+
+// adjacency maps every Service ID to the IDs of the Services its outgoing Relationships point to.
+// Relationships referencing undeclared Services are ignored.
+func (m *Config) adjacency() map[string][]string {
+	adjacency := make(map[string][]string)
+
+	for _, i := range m.Services {
+		adjacency[i.ID] = nil
+	}
+
+	for _, i := range m.Relationships {
+		_, fromExists := adjacency[i.From]
+		_, toExists := adjacency[i.To]
+		if fromExists && toExists {
+			adjacency[i.From] = append(adjacency[i.From], i.To)
+		}
+	}
+
+	return adjacency
+}
+
+// outgoing maps every Service ID to its outgoing Relationships, in declaration order.
+// Relationships referencing undeclared Services are ignored.
+func (m *Config) outgoing() map[string][]Relationship {
+	adjacency := m.adjacency()
+	outgoing := make(map[string][]Relationship)
+
+	for _, i := range m.Relationships {
+		_, fromExists := adjacency[i.From]
+		_, toExists := adjacency[i.To]
+		if fromExists && toExists {
+			outgoing[i.From] = append(outgoing[i.From], i)
+		}
+	}
+
+	return outgoing
+}
+
+// subset returns a new constellation, with the same Name and ID, holding the given Services (in declaration
+// order) and Relationships.
+func (m *Config) subset(services map[string]bool, relationships []Relationship) *Config {
+	res := &Config{Name: m.Name, ID: m.ID, Relationships: relationships, CaseMatching: m.CaseMatching}
+
+	for _, i := range m.Services {
+		if services[i.ID] {
+			res.Services = append(res.Services, i)
+		}
+	}
+
+	return res
+}
+
+// roots returns the sorted IDs of the Services without incoming Relationships.
+func roots(adjacency map[string][]string) (IDs []string) {
+	hasIncoming := make(map[string]bool)
+	for _, to := range adjacency {
+		for _, i := range to {
+			hasIncoming[i] = true
+		}
+	}
+
+	for id := range adjacency {
+		if !hasIncoming[id] {
+			IDs = append(IDs, id)
+		}
+	}
+
+	sort.Strings(IDs)
+	return
+}
+
+// reach returns the set of Services reachable from (and including) the given start Services.
+func reach(adjacency map[string][]string, start []string) map[string]bool {
+	reached := make(map[string]bool)
+	queue := append([]string{}, start...)
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if reached[id] {
+			continue
+		}
+		reached[id] = true
+		queue = append(queue, adjacency[id]...)
+	}
+
+	return reached
+}
+
+// components groups the Services into weakly connected components, ignoring Relationship direction.
+// Each component is sorted and the components are ordered by their first Service ID.
+func components(adjacency map[string][]string) (groups [][]string) {
+	undirected := make(map[string][]string)
+	for from, to := range adjacency {
+		for _, i := range to {
+			undirected[from] = append(undirected[from], i)
+			undirected[i] = append(undirected[i], from)
+		}
+	}
+
+	visited := make(map[string]bool)
+	for _, id := range sortedKeys(adjacency) {
+		if visited[id] {
+			continue
+		}
+
+		var group []string
+		for i := range reach(undirected, []string{id}) {
+			visited[i] = true
+			group = append(group, i)
+		}
+
+		sort.Strings(group)
+		groups = append(groups, group)
+	}
+
+	return
+}
+
+// reverse flips the direction of every edge of an adjacency map.
+func reverse(adjacency map[string][]string) map[string][]string {
+	reversed := make(map[string][]string, len(adjacency))
+
+	for from, to := range adjacency {
+		if _, exists := reversed[from]; !exists {
+			reversed[from] = nil
+		}
+		for _, i := range to {
+			reversed[i] = append(reversed[i], from)
+		}
+	}
+
+	return reversed
+}
+
+// without returns a copy of an adjacency map with the given Service, and all edges touching it, removed.
+func without(adjacency map[string][]string, serviceID string) map[string][]string {
+	res := make(map[string][]string, len(adjacency))
+
+	for from, to := range adjacency {
+		if from == serviceID {
+			continue
+		}
+		res[from] = nil
+		for _, i := range to {
+			if i != serviceID {
+				res[from] = append(res[from], i)
+			}
+		}
+	}
+
+	return res
+}
+
+// cyclic returns the sorted IDs of the Services which lie on a cycle, i.e. which can reach themselves.
+func cyclic(adjacency map[string][]string) (IDs []string) {
+	for _, id := range sortedKeys(adjacency) {
+		if reach(adjacency, adjacency[id])[id] {
+			IDs = append(IDs, id)
+		}
+	}
+
+	return
+}
+
+// sortedSet returns the members of a set of Service IDs in sorted order.
+func sortedSet(set map[string]bool) (IDs []string) {
+	for id := range set {
+		IDs = append(IDs, id)
+	}
+
+	sort.Strings(IDs)
+	return
+}
+
+// sortedKeys returns the Service IDs of an adjacency map in sorted order.
+func sortedKeys(adjacency map[string][]string) (IDs []string) {
+	for id := range adjacency {
+		IDs = append(IDs, id)
+	}
+
+	sort.Strings(IDs)
+	return
+}
+
+// bfs walks the graph breadth first from start, returning the distance of each reached Service and the Service
+// it was first reached from.
+func bfs(adjacency map[string][]string, start string) (map[string]int, map[string]string) {
+	distance := map[string]int{start: 0}
+	parent := make(map[string]string)
+	queue := []string{start}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, i := range adjacency[id] {
+			if _, seen := distance[i]; !seen {
+				distance[i] = distance[id] + 1
+				parent[i] = id
+				queue = append(queue, i)
+			}
+		}
+	}
+
+	return distance, parent
+}
+
+// trace follows the parents found by bfs back from end to start, returning the path start -> end.
+func trace(parent map[string]string, start string, end string) (path []string) {
+	for id := end; id != start; id = parent[id] {
+		path = append([]string{id}, path...)
+	}
+
+	return append([]string{start}, path...)
+}
+
+// unsorted returns the sorted Service IDs which are not part of the given order.
+func (m *Config) unsorted(order []string) (remaining []string) {
+	sorted := make(map[string]bool)
+	for _, i := range order {
+		sorted[i] = true
+	}
+
+	for _, i := range m.serviceIDs() {
+		if !sorted[i] {
+			remaining = append(remaining, i)
+		}
+	}
+
+	return
+}
+
+// idHeap is a min-heap of Service IDs.
+type idHeap []string
+
+func (h idHeap) Len() int            { return len(h) }
+func (h idHeap) Less(i, j int) bool  { return h[i] < h[j] }
+func (h idHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *idHeap) Push(x interface{}) { *h = append(*h, x.(string)) }
+func (h *idHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// shape returns sorted keys describing the Services (ID|Type) and Relationships (From|To) of a constellation.
+func (m *Config) shape() (services []string, relationships []string) {
+	for _, i := range m.Services {
+		services = append(services, i.ID+"|"+i.Type)
+	}
+
+	for _, i := range m.Relationships {
+		relationships = append(relationships, i.From+"|"+i.To)
+	}
+
+	sort.Strings(services)
+	sort.Strings(relationships)
+	return
+}
+
+// serviceIDs returns the distinct Service IDs in sorted order.
+func (m *Config) serviceIDs() (IDs []string) {
+	seen := make(map[string]bool)
+
+	for _, i := range m.Services {
+		if !seen[i.ID] {
+			seen[i.ID] = true
+			IDs = append(IDs, i.ID)
+		}
+	}
+
+	sort.Strings(IDs)
+	return
+}
@@ -0,0 +1,825 @@
+package constellation_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/microsoft/abstrakt/internal/platform/constellation"
+	"github.com/stretchr/testify/assert"
+)
+
+// buildConfig creates a constellation declaring the given Services and one
+// Relationship per From/To pair.
+func buildConfig(services []string, edges ...[2]string) *constellation.Config {
+	dag := &constellation.Config{Name: "Test", ID: "test"}
+
+	for _, i := range services {
+		dag.Services = append(dag.Services, constellation.Service{ID: i, Type: "Test", Properties: make(map[string]constellation.Property)})
+	}
+
+	for _, i := range edges {
+		dag.Relationships = append(dag.Relationships, constellation.Relationship{
+			ID:         fmt.Sprintf("%v to %v", i[0], i[1]),
+			From:       i[0],
+			To:         i[1],
+			Properties: make(map[string]constellation.Property),
+		})
+	}
+
+	return dag
+}
+
+func TestIntIDMap(t *testing.T) {
+	dag := buildConfig([]string{"c", "a", "b"}, [2]string{"a", "b"})
+
+	lookup, IDs := dag.IntIDMap()
+
+	assert.Equal(t, []string{"a", "b", "c"}, IDs)
+	assert.Len(t, lookup, len(IDs))
+
+	for i, id := range IDs {
+		assert.Equal(t, i, lookup[id])
+	}
+
+	lookupAgain, IDsAgain := dag.IntIDMap()
+	assert.Equal(t, lookup, lookupAgain)
+	assert.Equal(t, IDs, IDsAgain)
+}
+
+func TestStructurallyEqual(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	other := new(constellation.Config)
+	err = other.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	other.ID = "f0b8b9a2-7b5e-4a3c-9d7e-1c2b3a4d5e6f"
+	for i := range other.Relationships {
+		other.Relationships[i].ID = fmt.Sprintf("Regenerated %v", i)
+	}
+
+	assert.True(t, dag.StructurallyEqual(other), "Constellations differing only by IDs should be structurally equal")
+
+	other.Relationships[0].To = "Event Logger"
+	assert.False(t, dag.StructurallyEqual(other), "Constellations with different Relationships should not be structurally equal")
+}
+
+func TestStableTopologicalSort(t *testing.T) {
+	dag := buildConfig([]string{"d", "c", "b", "a", "e"},
+		[2]string{"a", "d"},
+		[2]string{"c", "d"},
+		[2]string{"b", "d"},
+		[2]string{"d", "e"},
+	)
+
+	expected := []string{"a", "b", "c", "d", "e"}
+
+	for i := 0; i < 10; i++ {
+		order, err := dag.StableTopologicalSort()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, order)
+	}
+}
+
+func TestStableTopologicalSortCycle(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "b"},
+	)
+
+	order, err := dag.StableTopologicalSort()
+	assert.Error(t, err)
+	assert.Nil(t, order)
+}
+
+func TestDeployBlockers(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "d"},
+		[2]string{"a", "d"},
+	)
+
+	blockers, err := dag.DeployBlockers("d")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, blockers)
+
+	blockers, err = dag.DeployBlockers("a")
+	assert.NoError(t, err)
+	assert.Empty(t, blockers)
+
+	_, err = dag.DeployBlockers("missing")
+	assert.Error(t, err)
+}
+
+func TestReachabilityCoverage(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"c", "d"},
+		[2]string{"d", "c"},
+	)
+
+	assert.Equal(t, 0.5, dag.ReachabilityCoverage())
+
+	assert.Equal(t, 0.0, new(constellation.Config).ReachabilityCoverage())
+}
+
+func TestStats(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e"},
+		[2]string{"a", "b"},
+		[2]string{"a", "c"},
+		[2]string{"b", "c"},
+		[2]string{"d", "e"},
+	)
+
+	expected := constellation.GraphStats{
+		Services:      5,
+		Relationships: 4,
+		MaxInDegree:   2,
+		MaxOutDegree:  2,
+		AverageDegree: 1.6,
+		Components:    2,
+		Acyclic:       true,
+	}
+
+	assert.Equal(t, expected, dag.Stats())
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "cycle", From: "e", To: "d"})
+	assert.False(t, dag.Stats().Acyclic)
+	assert.Equal(t, 2, dag.Stats().Components)
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "dangling", From: "a", To: "missing"})
+	assert.Equal(t, 5, dag.Stats().Relationships)
+	assert.Len(t, dag.Relationships, 6)
+
+	dag = buildConfig([]string{"a", "b", "c"}, [2]string{"a", "a"}, [2]string{"b", "c"}, [2]string{"c", "b"})
+	assert.Equal(t, constellation.GraphStats{
+		Services:      3,
+		Relationships: 3,
+		MaxInDegree:   1,
+		MaxOutDegree:  1,
+		AverageDegree: 2,
+		Components:    2,
+		Acyclic:       false,
+	}, dag.Stats())
+
+	assert.Equal(t, constellation.GraphStats{Acyclic: true}, new(constellation.Config).Stats())
+}
+
+func TestLevelTypeGrid(t *testing.T) {
+	dag := buildConfig([]string{"gen1", "gen2", "hub", "log1", "log2"},
+		[2]string{"gen1", "hub"},
+		[2]string{"gen2", "hub"},
+		[2]string{"hub", "log1"},
+		[2]string{"hub", "log2"},
+		[2]string{"gen1", "log2"},
+	)
+	dag.Services[0].Type = "EventGenerator"
+	dag.Services[1].Type = "EventGenerator"
+	dag.Services[2].Type = "EventHub"
+	dag.Services[3].Type = "EventLogger"
+	dag.Services[4].Type = "EventLogger"
+
+	grid, err := dag.LevelTypeGrid()
+	assert.NoError(t, err)
+
+	expected := map[int]map[string][]string{
+		0: {"EventGenerator": {"gen1", "gen2"}},
+		1: {"EventHub": {"hub"}},
+		2: {"EventLogger": {"log1", "log2"}},
+	}
+	assert.Equal(t, expected, grid)
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "cycle", From: "log1", To: "gen1"})
+	_, err = dag.LevelTypeGrid()
+	assert.Error(t, err)
+}
+
+func TestWouldCreateCycle(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+	)
+
+	assert.True(t, dag.WouldCreateCycle("c", "a"), "Back edge should create a cycle")
+	assert.True(t, dag.WouldCreateCycle("b", "b"), "Self loop should create a cycle")
+	assert.False(t, dag.WouldCreateCycle("a", "c"), "Forward edge should not create a cycle")
+	assert.False(t, dag.WouldCreateCycle("c", "d"), "Edge to an unconnected Service should not create a cycle")
+}
+
+func TestSuggestConnectingEdges(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"d", "c"},
+	)
+
+	assert.Equal(t, [][2]string{{"a", "c"}}, dag.SuggestConnectingEdges())
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "bridge", From: "b", To: "c"})
+	assert.Empty(t, dag.SuggestConnectingEdges())
+}
+
+func TestAncestors(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e"},
+		[2]string{"a", "c"},
+		[2]string{"b", "c"},
+		[2]string{"c", "d"},
+		[2]string{"d", "e"},
+	)
+
+	ancestors, err := dag.Ancestors("d")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, ancestors)
+
+	ancestors, err = dag.Ancestors("a")
+	assert.NoError(t, err)
+	assert.Empty(t, ancestors)
+
+	_, err = dag.Ancestors("missing")
+	assert.Error(t, err)
+}
+
+func TestSpanningTree(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e"},
+		[2]string{"a", "b"},
+		[2]string{"a", "c"},
+		[2]string{"b", "d"},
+		[2]string{"c", "d"},
+		[2]string{"d", "a"},
+		[2]string{"e", "a"},
+	)
+
+	tree, err := dag.SpanningTree("a")
+	assert.NoError(t, err)
+
+	assert.Len(t, tree.Services, 4)
+	assert.Nil(t, tree.FindService("e"))
+	assert.Len(t, tree.Relationships, len(tree.Services)-1)
+
+	_, err = dag.SpanningTree("missing")
+	assert.Error(t, err)
+}
+
+func TestDependentsCutBy(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e", "x", "y"},
+		[2]string{"y", "a"},
+		[2]string{"a", "c"},
+		[2]string{"b", "c"},
+		[2]string{"c", "d"},
+		[2]string{"c", "e"},
+		[2]string{"x", "c"},
+		[2]string{"x", "d"},
+	)
+
+	cut, err := dag.DependentsCutBy("c")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "y"}, cut)
+
+	cut, err = dag.DependentsCutBy("d")
+	assert.NoError(t, err)
+	assert.Empty(t, cut)
+
+	_, err = dag.DependentsCutBy("missing")
+	assert.Error(t, err)
+}
+
+func TestEdgeBetweenness(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e", "f"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"a", "c"},
+		[2]string{"c", "d"},
+		[2]string{"d", "e"},
+		[2]string{"e", "f"},
+		[2]string{"d", "f"},
+	)
+
+	scores := dag.EdgeBetweenness()
+	assert.Len(t, scores, len(dag.Relationships))
+
+	bridge := &dag.Relationships[3]
+	assert.Equal(t, 9.0, scores[bridge])
+
+	for i, score := range scores {
+		if i != bridge {
+			assert.Less(t, score, scores[bridge], "Relationship '%v' should score lower than the bridge", i.ID)
+		}
+	}
+}
+
+func TestImpactCounts(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "d"},
+		[2]string{"d", "e"},
+	)
+
+	upstream, downstream, err := dag.ImpactCounts("c")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, upstream)
+	assert.Equal(t, 2, downstream)
+
+	_, _, err = dag.ImpactCounts("missing")
+	assert.Error(t, err)
+}
+
+func TestReachableDiamond(t *testing.T) {
+	dag := buildConfig([]string{"top", "left", "right", "bottom", "after", "unrelated"},
+		[2]string{"top", "right"},
+		[2]string{"top", "left"},
+		[2]string{"left", "bottom"},
+		[2]string{"right", "bottom"},
+		[2]string{"bottom", "after"},
+	)
+
+	reached, err := dag.Reachable("top")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"left", "right", "bottom", "after"}, reached)
+
+	reached, err = dag.Reachable("after")
+	assert.NoError(t, err)
+	assert.Empty(t, reached)
+
+	_, err = dag.Reachable("missing")
+	assert.Error(t, err)
+}
+
+func TestReachableCycle(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "a"},
+		[2]string{"c", "d"},
+	)
+
+	reached, err := dag.Reachable("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "d"}, reached)
+}
+
+func TestShortestPath(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "d"},
+		[2]string{"a", "c"},
+		[2]string{"e", "a"},
+	)
+
+	path, err := dag.ShortestPath("a", "d")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "d"}, path)
+
+	path, err = dag.ShortestPath("d", "a")
+	assert.NoError(t, err)
+	assert.Nil(t, path)
+
+	path, err = dag.ShortestPath("b", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, path)
+
+	_, err = dag.ShortestPath("a", "missing")
+	assert.Error(t, err)
+}
+
+func TestChangeImpact(t *testing.T) {
+	dag := buildConfig([]string{"upstream", "a", "b", "c", "d", "unrelated"},
+		[2]string{"upstream", "a"},
+		[2]string{"a", "b"},
+		[2]string{"a", "c"},
+		[2]string{"c", "d"},
+		[2]string{"b", "d"},
+		[2]string{"unrelated", "d"},
+	)
+
+	services, relationships, err := dag.ChangeImpact("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "d"}, services)
+
+	var IDs []string
+	for _, i := range relationships {
+		IDs = append(IDs, i.ID)
+	}
+	assert.Equal(t, []string{"a to b", "a to c", "c to d", "b to d"}, IDs)
+
+	services, relationships, err = dag.ChangeImpact("d")
+	assert.NoError(t, err)
+	assert.Empty(t, services)
+	assert.Empty(t, relationships)
+
+	_, _, err = dag.ChangeImpact("missing")
+	assert.Error(t, err)
+}
+
+func TestMandatoryIntermediates(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e", "f"},
+		[2]string{"a", "b"},
+		[2]string{"a", "c"},
+		[2]string{"b", "d"},
+		[2]string{"c", "d"},
+		[2]string{"d", "e"},
+		[2]string{"e", "f"},
+		[2]string{"d", "f"},
+	)
+
+	mandatory, err := dag.MandatoryIntermediates("a", "f")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d"}, mandatory)
+
+	_, err = dag.MandatoryIntermediates("f", "a")
+	assert.Error(t, err)
+
+	_, err = dag.MandatoryIntermediates("a", "missing")
+	assert.Error(t, err)
+}
+
+func TestFindDescriptionCycles(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "a"},
+		[2]string{"a", "d"},
+		[2]string{"d", "a"},
+	)
+	for i := range dag.Relationships[:3] {
+		dag.Relationships[i].Description = "replicates-to"
+	}
+	dag.Relationships[3].Description = "reads-from"
+	dag.Relationships[4].Description = "writes-to"
+
+	expected := map[string][]string{
+		"replicates-to": {"a", "b", "c"},
+	}
+	assert.Equal(t, expected, dag.FindDescriptionCycles())
+}
+
+func TestTypeEdgeCounts(t *testing.T) {
+	dag := buildConfig([]string{"gen", "hub1", "hub2", "log"},
+		[2]string{"gen", "hub1"},
+		[2]string{"hub1", "hub2"},
+		[2]string{"hub2", "log"},
+		[2]string{"gen", "log"},
+	)
+	dag.Services[0].Type = "EventGenerator"
+	dag.Services[1].Type = "EventHub"
+	dag.Services[2].Type = "EventHub"
+	dag.Services[3].Type = "EventLogger"
+
+	expected := map[string]int{
+		"EventGenerator": 2,
+		"EventHub":       3,
+		"EventLogger":    2,
+	}
+	assert.Equal(t, expected, dag.TypeEdgeCounts())
+}
+
+func TestBatchSchedule(t *testing.T) {
+	dag := buildConfig([]string{"root", "a", "b", "c", "d", "e", "sink"},
+		[2]string{"root", "a"},
+		[2]string{"root", "b"},
+		[2]string{"root", "c"},
+		[2]string{"root", "d"},
+		[2]string{"root", "e"},
+		[2]string{"a", "sink"},
+		[2]string{"e", "sink"},
+	)
+
+	batches, err := dag.BatchSchedule(2)
+	assert.NoError(t, err)
+
+	expected := [][]string{{"root"}, {"a", "b"}, {"c", "d"}, {"e"}, {"sink"}}
+	assert.Equal(t, expected, batches)
+
+	_, err = dag.BatchSchedule(0)
+	assert.Error(t, err)
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "cycle", From: "sink", To: "root"})
+	_, err = dag.BatchSchedule(2)
+	assert.Error(t, err)
+}
+
+func TestFrontier(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "d"},
+	)
+
+	frontier := dag.Frontier([]string{"a"})
+	assert.Equal(t, []string{"b"}, frontier)
+
+	frontier = dag.Frontier(append([]string{"a"}, frontier...))
+	assert.Equal(t, []string{"c"}, frontier)
+
+	assert.Empty(t, dag.Frontier([]string{"a", "b", "c", "d"}))
+}
+
+func TestShortestCycleThrough(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "d"},
+		[2]string{"d", "a"},
+		[2]string{"b", "e"},
+		[2]string{"e", "a"},
+		[2]string{"c", "c"},
+	)
+
+	cycle, err := dag.ShortestCycleThrough("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "e"}, cycle)
+
+	cycle, err = dag.ShortestCycleThrough("c")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, cycle)
+
+	dag = buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	_, err = dag.ShortestCycleThrough("a")
+	assert.Error(t, err)
+
+	_, err = dag.ShortestCycleThrough("missing")
+	assert.Error(t, err)
+}
+
+func TestComponentCount(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e", "f"},
+		[2]string{"a", "b"},
+		[2]string{"c", "b"},
+		[2]string{"d", "e"},
+	)
+
+	assert.Equal(t, 3, dag.ComponentCount())
+	assert.Equal(t, 0, new(constellation.Config).ComponentCount())
+}
+
+func TestFindCycleOnlyServices(t *testing.T) {
+	dag := buildConfig([]string{"root", "a", "b", "c", "d"},
+		[2]string{"root", "a"},
+		[2]string{"a", "b"},
+		[2]string{"b", "a"},
+		[2]string{"b", "c"},
+		[2]string{"root", "d"},
+	)
+
+	assert.Equal(t, []string{"b", "c"}, dag.FindCycleOnlyServices())
+
+	dag = buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	assert.Empty(t, dag.FindCycleOnlyServices())
+}
+
+func TestDetectCycles(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e", "f"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "a"},
+		[2]string{"d", "e"},
+		[2]string{"e", "d"},
+		[2]string{"f", "f"},
+	)
+
+	cycles, err := dag.DetectCycles()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"d", "e"}, {"f"}}, cycles)
+}
+
+func TestDetectCyclesNone(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	cycles, err := dag.DetectCycles()
+	assert.NoError(t, err)
+	assert.NotNil(t, cycles)
+	assert.Empty(t, cycles)
+}
+
+func TestFeedbackEdgeSet(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "a"},
+		[2]string{"c", "d"},
+	)
+
+	feedback := dag.FeedbackEdgeSet()
+	assert.Len(t, feedback, 1)
+	assert.Equal(t, "c to a", feedback[0].ID)
+
+	dag.Relationships = dag.Relationships[:2]
+	assert.Empty(t, dag.FeedbackEdgeSet())
+}
+
+func TestFeedbackEdgeSetBreaksAllCycles(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"},
+		[2]string{"a", "b"},
+		[2]string{"b", "a"},
+		[2]string{"b", "c"},
+		[2]string{"c", "b"},
+		[2]string{"c", "c"},
+	)
+
+	removed := make(map[string]bool)
+	for _, i := range dag.FeedbackEdgeSet() {
+		removed[i.ID] = true
+	}
+
+	var kept []constellation.Relationship
+	for _, i := range dag.Relationships {
+		if !removed[i.ID] {
+			kept = append(kept, i)
+		}
+	}
+	dag.Relationships = kept
+
+	_, err := dag.TopologicalSort()
+	assert.NoError(t, err)
+}
+
+func TestDetectCyclesMissingService(t *testing.T) {
+	dag := buildConfig([]string{"a"}, [2]string{"a", "missing"})
+
+	_, err := dag.DetectCycles()
+	assert.Error(t, err)
+}
+
+func TestTopologicalSortChain(t *testing.T) {
+	dag := buildConfig([]string{"c", "b", "a", "isolated"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+	)
+
+	order, err := dag.TopologicalSort()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "isolated", "b", "c"}, order)
+}
+
+func TestTopologicalSortDiamond(t *testing.T) {
+	dag := buildConfig([]string{"top", "left", "right", "bottom"},
+		[2]string{"top", "left"},
+		[2]string{"top", "right"},
+		[2]string{"left", "bottom"},
+		[2]string{"right", "bottom"},
+	)
+
+	order, err := dag.TopologicalSort()
+	assert.NoError(t, err)
+	assert.Len(t, order, 4)
+
+	position := make(map[string]int)
+	for i, id := range order {
+		position[id] = i
+	}
+	for _, i := range dag.Relationships {
+		assert.Less(t, position[i.From], position[i.To], "'%v' should come before '%v'", i.From, i.To)
+	}
+}
+
+func TestTopologicalSortCycle(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "b"},
+	)
+
+	order, err := dag.TopologicalSort()
+	assert.Nil(t, order)
+
+	var cycle *constellation.CycleError
+	assert.True(t, errors.As(err, &cycle))
+	assert.Equal(t, []string{"b", "c"}, cycle.Services)
+}
+
+func TestSliceByDepth(t *testing.T) {
+	dag := buildConfig([]string{"root", "a", "b", "c", "leaf"},
+		[2]string{"root", "a"},
+		[2]string{"root", "b"},
+		[2]string{"a", "c"},
+		[2]string{"b", "c"},
+		[2]string{"a", "b"},
+		[2]string{"c", "leaf"},
+	)
+
+	slice, err := dag.SliceByDepth(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", slice.Name)
+	assert.Len(t, slice.Services, 2)
+	assert.NotNil(t, slice.FindService("a"))
+	assert.NotNil(t, slice.FindService("b"))
+	assert.Len(t, slice.Relationships, 1)
+	assert.Equal(t, "a to b", slice.Relationships[0].ID)
+
+	slice, err = dag.SliceByDepth(3, 4)
+	assert.NoError(t, err)
+	assert.Len(t, slice.Services, 2)
+	assert.Len(t, slice.Relationships, 1)
+	assert.Len(t, dag.Services, 5, "The original should be unchanged")
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "leaf to root", From: "leaf", To: "root"})
+	_, err = dag.SliceByDepth(0, 1)
+	assert.Error(t, err)
+}
+
+func TestExtractSubgraph(t *testing.T) {
+	dag := buildConfig([]string{"top", "left", "right", "bottom", "right-only"},
+		[2]string{"top", "left"},
+		[2]string{"top", "right"},
+		[2]string{"left", "bottom"},
+		[2]string{"right", "bottom"},
+		[2]string{"right", "right-only"},
+	)
+
+	sub, err := dag.ExtractSubgraph([]string{"left"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Test subgraph", sub.Name)
+	assert.Len(t, sub.Services, 2)
+	assert.NotNil(t, sub.FindService("left"))
+	assert.NotNil(t, sub.FindService("bottom"))
+	assert.Nil(t, sub.FindService("right"))
+	assert.Nil(t, sub.FindService("right-only"))
+	assert.Len(t, sub.Relationships, 1)
+	assert.Equal(t, "left to bottom", sub.Relationships[0].ID)
+	assert.Len(t, dag.Services, 5, "The original should be unchanged")
+
+	sub, err = dag.ExtractSubgraph([]string{"left", "right"})
+	assert.NoError(t, err)
+	assert.Len(t, sub.Services, 4)
+	assert.Len(t, sub.Relationships, 3)
+
+	_, err = dag.ExtractSubgraph([]string{"missing"})
+	assert.Error(t, err)
+}
+
+func TestLongestSharedSubpath(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e", "x", "y"})
+
+	shared := dag.LongestSharedSubpath([]string{"a", "b", "c", "d", "e"}, []string{"x", "b", "c", "d", "y"})
+	assert.Equal(t, []string{"b", "c", "d"}, shared)
+
+	shared = dag.LongestSharedSubpath([]string{"a", "b", "x", "c", "d"}, []string{"c", "d", "y", "a", "b"})
+	assert.Equal(t, []string{"a", "b"}, shared)
+
+	assert.Nil(t, dag.LongestSharedSubpath([]string{"a", "b"}, []string{"x", "y"}))
+	assert.Nil(t, dag.LongestSharedSubpath(nil, []string{"x"}))
+}
+
+func TestOutDegreeDistribution(t *testing.T) {
+	dag := buildConfig([]string{"hub", "a", "b", "c", "d"},
+		[2]string{"hub", "a"},
+		[2]string{"hub", "b"},
+		[2]string{"hub", "c"},
+		[2]string{"hub", "d"},
+	)
+
+	assert.Equal(t, map[int]int{0: 4, 4: 1}, dag.OutDegreeDistribution())
+	assert.Empty(t, buildConfig(nil).OutDegreeDistribution())
+}
+
+func TestIsSimpleGraph(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"},
+		[2]string{"a", "b"},
+		[2]string{"b", "a"},
+	)
+	assert.True(t, dag.IsSimpleGraph())
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "a to b again", From: "a", To: "b"})
+	assert.False(t, dag.IsSimpleGraph())
+}
+
+func TestBridges(t *testing.T) {
+	dag := buildConfig([]string{"a1", "a2", "a3", "b1", "b2", "b3"},
+		[2]string{"a1", "a2"},
+		[2]string{"a2", "a3"},
+		[2]string{"a3", "a1"},
+		[2]string{"a3", "b1"},
+		[2]string{"b1", "b2"},
+		[2]string{"b2", "b3"},
+		[2]string{"b3", "b1"},
+	)
+
+	bridges := dag.Bridges()
+	if assert.Len(t, bridges, 1) {
+		assert.Equal(t, "a3 to b1", bridges[0].ID)
+	}
+
+	dag.Relationships = append(dag.Relationships, constellation.Relationship{ID: "b1 to a3", From: "b1", To: "a3"})
+	assert.Empty(t, dag.Bridges(), "A parallel Relationship keeps the halves connected")
+}
+
+func TestBridgesChain(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "isolated"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+		[2]string{"c", "c"},
+	)
+
+	bridges := dag.Bridges()
+	if assert.Len(t, bridges, 2) {
+		assert.Equal(t, "a to b", bridges[0].ID)
+		assert.Equal(t, "b to c", bridges[1].ID)
+	}
+}
@@ -0,0 +1,459 @@
+package constellation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microsoft/abstrakt/tools/find"
+	"github.com/microsoft/abstrakt/tools/guid"
+)
+
+// guidLike matches IDs opening with the 8 hex digit first group of a GUID, which are taken as meant to be GUIDs.
+var guidLike = regexp.MustCompile(`^[0-9a-fA-F]{8}(-|$)`)
+
+// dnsLabel matches a RFC 1123 DNS label, the length limit is checked separately.
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// ValidateMinConnections reports every Service which takes part in fewer than min Relationships.
+func (m *Config) ValidateMinConnections(min int) (errs []error) {
+	connections := make(map[string]int)
+
+	for _, i := range m.Relationships {
+		connections[i.From]++
+		if i.To != i.From {
+			connections[i.To]++
+		}
+	}
+
+	for _, i := range m.Services {
+		if connections[i.ID] < min {
+			errs = append(errs, fmt.Errorf("Service '%v' has %v relationship(s), expected at least %v", i.ID, connections[i.ID], min))
+		}
+	}
+
+	return
+}
+
+// ValidateReservedKeys reports every Service and Relationship using one of the reserved keys in its Properties.
+func (m *Config) ValidateReservedKeys(reserved []string) (errs []error) {
+	for _, i := range m.Services {
+		for _, key := range reserved {
+			if _, exists := i.Properties[key]; exists {
+				errs = append(errs, fmt.Errorf("Service '%v' uses reserved property '%v'", i.ID, key))
+			}
+		}
+	}
+
+	for _, i := range m.Relationships {
+		for _, key := range reserved {
+			if _, exists := i.Properties[key]; exists {
+				errs = append(errs, fmt.Errorf("Relationship '%v' uses reserved property '%v'", i.ID, key))
+			}
+		}
+	}
+
+	return
+}
+
+// ValidateRelationshipIDs reports every Relationship with an empty ID, such Relationships can't be resolved by
+// FindRelationship. Unlike ValidateModel all offending Relationships are reported.
+func (m *Config) ValidateRelationshipIDs() (errs []error) {
+	for n, i := range m.Relationships {
+		if strings.TrimSpace(i.ID) == "" {
+			errs = append(errs, fmt.Errorf("Relationship %v from '%v' to '%v' has no ID", n, i.From, i.To))
+		}
+	}
+
+	return
+}
+
+// IsDeployable checks that every Relationship references declared Services, that the constellation is acyclic
+// and that it has at least one root Service to start the deployment from.
+func (m *Config) IsDeployable() (bool, []error) {
+	var errs []error
+
+	missing := m.ServiceExists()
+	for _, i := range m.Relationships {
+		for _, j := range missing[i.ID] {
+			errs = append(errs, fmt.Errorf("Relationship '%v' references missing Service '%v'", i.ID, j))
+		}
+		// only report once for Relationships sharing an ID
+		delete(missing, i.ID)
+	}
+
+	if _, err := m.StableTopologicalSort(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(roots(m.adjacency())) == 0 {
+		errs = append(errs, fmt.Errorf("Constellation has no root Service"))
+	}
+
+	return len(errs) == 0, errs
+}
+
+// ValidateDNSNames reports every Service whose ID isn't a valid RFC 1123 DNS label: at most 63 lower case
+// alphanumeric characters or '-', starting and ending with an alphanumeric character.
+func (m *Config) ValidateDNSNames() (errs []error) {
+	for _, i := range m.Services {
+		if len(i.ID) > 63 || !dnsLabel.MatchString(i.ID) {
+			errs = append(errs, fmt.Errorf("Service '%v' is not a valid DNS label", i.ID))
+		}
+	}
+
+	return
+}
+
+// ValidateRelationshipIDUniqueness reports every Relationship ID used more than once, only the first
+// Relationship with a given ID can be resolved by FindRelationship.
+func (m *Config) ValidateRelationshipIDUniqueness() (errs []error) {
+	count := make(map[string]int)
+
+	for _, i := range m.Relationships {
+		count[i.ID]++
+		if count[i.ID] == 2 {
+			errs = append(errs, fmt.Errorf("Relationship ID '%v' is not unique", i.ID))
+		}
+	}
+
+	return
+}
+
+// ValidatePropertyRange reports every Service whose Properties[key] is not a number within [min, max].
+// Services without the Property are not reported.
+func (m *Config) ValidatePropertyRange(key string, min float64, max float64) (errs []error) {
+	for _, i := range m.Services {
+		value, exists := i.Properties[key]
+		if !exists {
+			continue
+		}
+
+		number, ok := toFloat(value)
+		if !ok {
+			errs = append(errs, fmt.Errorf("Service '%v' property '%v' is not a number: %v", i.ID, key, value))
+		} else if number < min || number > max {
+			errs = append(errs, fmt.Errorf("Service '%v' property '%v' is %v, expected between %v and %v", i.ID, key, value, min, max))
+		}
+	}
+
+	return
+}
+
+// ValidateEntityBudget reports if the constellation declares more Services or Relationships than allowed.
+func (m *Config) ValidateEntityBudget(maxServices int, maxRelationships int) (errs []error) {
+	if len(m.Services) > maxServices {
+		errs = append(errs, fmt.Errorf("Constellation has %v services, the budget is %v", len(m.Services), maxServices))
+	}
+
+	if len(m.Relationships) > maxRelationships {
+		errs = append(errs, fmt.Errorf("Constellation has %v relationships, the budget is %v", len(m.Relationships), maxRelationships))
+	}
+
+	return
+}
+
+// ValidateDescriptionLength reports every Relationship whose Description is longer than max characters.
+func (m *Config) ValidateDescriptionLength(max int) (errs []error) {
+	for _, i := range m.Relationships {
+		if length := utf8.RuneCountInString(i.Description); length > max {
+			errs = append(errs, fmt.Errorf("Relationship '%v' description has %v characters, the maximum is %v", i.ID, length, max))
+		}
+	}
+
+	return
+}
+
+// ValidateTypeOrdering reports every Relationship connecting Service Types the policy doesn't allow. The policy
+// maps a From Service Type to the To Service Types it may connect to. Relationships referencing undeclared
+// Services are not reported, see ServiceExists.
+func (m *Config) ValidateTypeOrdering(allowed map[string][]string) (errs []error) {
+	for _, i := range m.Relationships {
+		from := m.FindService(i.From)
+		to := m.FindService(i.To)
+		if from == nil || to == nil {
+			continue
+		}
+
+		if _, exists := find.Slice(allowed[from.Type], to.Type); !exists {
+			errs = append(errs, fmt.Errorf("Relationship '%v' from '%v' to '%v' is not allowed", i.ID, from.Type, to.Type))
+		}
+	}
+
+	return
+}
+
+// ValidateUniqueRelationshipTriples reports Relationships with the same From, To and Description as an earlier
+// Relationship, which are almost certainly duplicates.
+func (m *Config) ValidateUniqueRelationshipTriples() (errs []error) {
+	first := make(map[[3]string]string)
+
+	for _, i := range m.Relationships {
+		triple := [3]string{i.From, i.To, i.Description}
+		if id, exists := first[triple]; exists {
+			errs = append(errs, fmt.Errorf("Relationship '%v' duplicates '%v'", i.ID, id))
+		} else {
+			first[triple] = i.ID
+		}
+	}
+
+	return
+}
+
+// ExpectType returns an error if the Service does not exist or its Type isn't the expected one.
+// Types differing only in case match when the constellation is CaseInsensitive.
+func (m *Config) ExpectType(serviceID string, expected string) error {
+	service := m.FindService(serviceID)
+	if service == nil {
+		return fmt.Errorf("Service '%v' not found", serviceID)
+	}
+
+	if service.Type == expected || (m.CaseInsensitive() && strings.EqualFold(service.Type, expected)) {
+		return nil
+	}
+
+	return fmt.Errorf("Service '%v' has type '%v', expected '%v'", service.ID, service.Type, expected)
+}
+
+// ValidatePropertyEnum reports every Service whose Properties[key] is not one of the allowed strings.
+// Services without the Property are not reported.
+func (m *Config) ValidatePropertyEnum(key string, allowed []string) (errs []error) {
+	for _, i := range m.Services {
+		value, exists := i.Properties[key]
+		if !exists {
+			continue
+		}
+
+		text, ok := value.(string)
+		if _, valid := find.Slice(allowed, text); !ok || !valid {
+			errs = append(errs, fmt.Errorf("Service '%v' property '%v' is %v, expected one of %v", i.ID, key, value, allowed))
+		}
+	}
+
+	return
+}
+
+// ValidateReferences reports every Relationship endpoint (From or To) which doesn't resolve to a Service, as
+// well as Relationships from a Service to itself. Endpoints must match a Service ID exactly, as the graph methods
+// ignore Relationships whose endpoints only match in a different case, so those are reported as miscased.
+// An empty result means the constellation is referentially sound.
+func (m *Config) ValidateReferences() (errs []error) {
+	IDs := make(map[string]bool)
+	for _, i := range m.Services {
+		IDs[i.ID] = true
+	}
+
+	check := func(relationship Relationship, field string, endpoint string) {
+		if IDs[endpoint] {
+			return
+		}
+		if service := m.FindService(endpoint); service != nil {
+			errs = append(errs, fmt.Errorf("Relationship '%v' references %v service '%v' miscased as '%v'", relationship.ID, field, service.ID, endpoint))
+		} else {
+			errs = append(errs, fmt.Errorf("Relationship '%v' references missing %v service '%v'", relationship.ID, field, endpoint))
+		}
+	}
+
+	for _, i := range m.Relationships {
+		check(i, "From", i.From)
+		check(i, "To", i.To)
+		if i.From == i.To {
+			errs = append(errs, fmt.Errorf("Relationship '%v' connects service '%v' to itself", i.ID, i.From))
+		}
+	}
+
+	return
+}
+
+// ValidateIsTree returns an error unless the constellation is a tree: connected, acyclic and with exactly one
+// incoming Relationship for every Service except the root.
+func (m *Config) ValidateIsTree() error {
+	if components := m.ComponentCount(); components != 1 {
+		return fmt.Errorf("Constellation should be connected, found %v components", components)
+	}
+
+	if _, err := m.StableTopologicalSort(); err != nil {
+		return err
+	}
+
+	incoming := make(map[string]int)
+	for _, to := range m.adjacency() {
+		for _, i := range to {
+			incoming[i]++
+		}
+	}
+
+	for _, i := range m.serviceIDs() {
+		if incoming[i] > 1 {
+			return fmt.Errorf("Service '%v' has %v incoming relationships, expected at most 1", i, incoming[i])
+		}
+	}
+
+	return nil
+}
+
+// ValidateIntraGroupEdges reports Relationships between Services in different Name-prefix groups (see
+// GroupByNamePrefix), so that each group only depends on itself.
+func (m *Config) ValidateIntraGroupEdges(sep string) (errs []error) {
+	for _, i := range m.Relationships {
+		if from, to := namePrefix(i.From, sep), namePrefix(i.To, sep); from != to {
+			errs = append(errs, fmt.Errorf("Relationship '%v' crosses from group '%v' to group '%v'", i.ID, from, to))
+		}
+	}
+
+	return
+}
+
+// ValidateNoPlaceholders reports every Service and Relationship whose ID is one of the given placeholder strings
+// such as "TODO".
+func (m *Config) ValidateNoPlaceholders(placeholders []string) (errs []error) {
+	for _, i := range m.Services {
+		if _, exists := find.Slice(placeholders, i.ID); exists {
+			errs = append(errs, fmt.Errorf("Service '%v' has a placeholder ID", i.ID))
+		}
+	}
+
+	for _, i := range m.Relationships {
+		if _, exists := find.Slice(placeholders, i.ID); exists {
+			errs = append(errs, fmt.Errorf("Relationship '%v' has a placeholder ID", i.ID))
+		}
+	}
+
+	return
+}
+
+// ValidateGUIDVersion reports the constellation ID unless it is a GUID of the given version, and every Service
+// and Relationship ID which is a GUID of another version. Service and Relationship IDs which aren't GUIDs at all
+// are allowed, as they are usually names.
+func (m *Config) ValidateGUIDVersion(version int) (errs []error) {
+	if found, ok := m.ID.Version(); !ok {
+		errs = append(errs, fmt.Errorf("Constellation ID '%v' is not a GUID", m.ID))
+	} else if found != version {
+		errs = append(errs, fmt.Errorf("Constellation ID '%v' is a version %v GUID, expected version %v", m.ID, found, version))
+	}
+
+	for _, i := range m.Services {
+		if found, ok := guid.GUID(i.ID).Version(); ok && found != version {
+			errs = append(errs, fmt.Errorf("Service '%v' is a version %v GUID, expected version %v", i.ID, found, version))
+		}
+	}
+
+	for _, i := range m.Relationships {
+		if found, ok := guid.GUID(i.ID).Version(); ok && found != version {
+			errs = append(errs, fmt.Errorf("Relationship '%v' is a version %v GUID, expected version %v", i.ID, found, version))
+		}
+	}
+
+	return
+}
+
+// ValidateRootType reports every root Service, i.e. without incoming Relationships, whose Type isn't the expected
+// one (see ExpectType).
+func (m *Config) ValidateRootType(expected string) (errs []error) {
+	for _, i := range roots(m.adjacency()) {
+		if err := m.ExpectType(i, expected); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return
+}
+
+// ValidatePartitionable reports if the Services can't be split into n non-empty partitions without the
+// Relationships listed in noCut crossing between partitions, i.e. if the noCut Relationships bind the Services
+// into fewer than n groups. Unknown Relationships in noCut are reported too.
+func (m *Config) ValidatePartitionable(n int, noCut []string) (errs []error) {
+	if n < 1 {
+		return []error{fmt.Errorf("Partition count %v should be at least 1", n)}
+	}
+
+	bound := make(map[string][]string)
+	for _, i := range m.Services {
+		bound[i.ID] = nil
+	}
+
+	for _, id := range noCut {
+		relationship := m.FindRelationship(id)
+		if relationship == nil {
+			errs = append(errs, fmt.Errorf("Relationship '%v' not found", id))
+			continue
+		}
+
+		_, fromExists := bound[relationship.From]
+		_, toExists := bound[relationship.To]
+		if fromExists && toExists {
+			bound[relationship.From] = append(bound[relationship.From], relationship.To)
+		}
+	}
+
+	if groups := components(bound); len(groups) < n {
+		errs = append(errs, fmt.Errorf("Constellation can't be split into %v partitions without cutting %v, the most possible is %v", n, noCut, len(groups)))
+	}
+
+	return
+}
+
+// ValidateLeafTypes reports every leaf Service, i.e. without outgoing Relationships, whose Type isn't one of the
+// allowed terminal Types.
+func (m *Config) ValidateLeafTypes(allowed []string) (errs []error) {
+	adjacency := m.adjacency()
+	for _, i := range sortedKeys(adjacency) {
+		if len(adjacency[i]) > 0 {
+			continue
+		}
+		if service := m.FindService(i); service != nil {
+			if _, exists := find.Slice(allowed, service.Type); !exists {
+				errs = append(errs, fmt.Errorf("Leaf service '%v' has type '%v', expected one of %v", i, service.Type, allowed))
+			}
+		}
+	}
+
+	return
+}
+
+// ValidateGUIDs reports an empty or malformed constellation ID, and every empty Service ID, Relationship ID, From or
+// To. Service and Relationship IDs are usually names, so they are only reported as malformed when they look like a
+// GUID, opening with its 8 hex digit first group, without being in the canonical form, e.g. a truncated one.
+func (m *Config) ValidateGUIDs() (errs []error) {
+	if m.ID.IsEmpty() {
+		errs = append(errs, fmt.Errorf("Constellation '%v' has an empty ID", m.Name))
+	} else if !m.ID.IsValid() {
+		errs = append(errs, fmt.Errorf("Constellation '%v' has a malformed ID '%v'", m.Name, m.ID))
+	}
+
+	check := func(owner string, field string, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%v has an empty %v", owner, field))
+		} else if guidLike.MatchString(value) && !guid.GUID(value).IsValid() {
+			errs = append(errs, fmt.Errorf("%v has a malformed %v '%v'", owner, field, value))
+		}
+	}
+
+	for n, i := range m.Services {
+		check(fmt.Sprintf("Service %v '%v'", n, i.ID), "ID", i.ID)
+	}
+
+	for n, i := range m.Relationships {
+		owner := fmt.Sprintf("Relationship %v '%v'", n, i.ID)
+		check(owner, "ID", i.ID)
+		check(owner, "From", i.From)
+		check(owner, "To", i.To)
+	}
+
+	return
+}
+
+// ValidateCaseInsensitiveNameUniqueness reports every pair of Services whose IDs differ only in case, which the
+// Find methods can't tell apart on a CaseInsensitive constellation. Exact duplicates are left to FindDuplicateIDs.
+func (m *Config) ValidateCaseInsensitiveNameUniqueness() (errs []error) {
+	for i, a := range m.Services {
+		for _, b := range m.Services[i+1:] {
+			if a.ID != b.ID && strings.EqualFold(a.ID, b.ID) {
+				errs = append(errs, fmt.Errorf("Services '%v' and '%v' differ only in case", a.ID, b.ID))
+			}
+		}
+	}
+
+	return
+}
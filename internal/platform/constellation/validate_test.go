@@ -0,0 +1,394 @@
+package constellation_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/microsoft/abstrakt/internal/platform/constellation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMinConnections(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"}, [2]string{"a", "b"})
+
+	errs := dag.ValidateMinConnections(1)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'c'")
+
+	assert.Empty(t, dag.ValidateMinConnections(0))
+}
+
+func TestValidateReservedKeys(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	dag.Services[1].Properties["__internal"] = "engine"
+	dag.Services[1].Properties["replicas"] = 2
+
+	errs := dag.ValidateReservedKeys([]string{"__internal"})
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'b'")
+
+	dag.Relationships[0].Properties["__internal"] = true
+	assert.Len(t, dag.ValidateReservedKeys([]string{"__internal"}), 2)
+}
+
+func TestValidateRelationshipIDs(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"}, [2]string{"a", "b"}, [2]string{"b", "c"})
+	assert.Empty(t, dag.ValidateRelationshipIDs())
+
+	dag.Relationships[1].ID = ""
+
+	errs := dag.ValidateRelationshipIDs()
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "from 'b' to 'c'")
+}
+
+func TestIsDeployable(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	deployable, errs := dag.IsDeployable()
+	assert.True(t, deployable)
+	assert.Empty(t, errs)
+}
+
+func TestIsDeployableFail(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"},
+		[2]string{"a", "b"},
+		[2]string{"b", "a"},
+		[2]string{"b", "missing"},
+	)
+
+	deployable, errs := dag.IsDeployable()
+	assert.False(t, deployable)
+	assert.Len(t, errs, 3)
+}
+
+func TestValidateDNSNames(t *testing.T) {
+	dag := buildConfig([]string{"event-hub", "logger2", "event_generator", "-web", strings.Repeat("a", 64)})
+
+	errs := dag.ValidateDNSNames()
+	assert.Len(t, errs, 3)
+	assert.Contains(t, errs[0].Error(), "'event_generator'")
+}
+
+func TestValidateRelationshipIDUniqueness(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"}, [2]string{"a", "b"}, [2]string{"b", "c"}, [2]string{"a", "c"})
+	assert.Empty(t, dag.ValidateRelationshipIDUniqueness())
+
+	dag.Relationships[2].ID = dag.Relationships[0].ID
+
+	errs := dag.ValidateRelationshipIDUniqueness()
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'a to b'")
+}
+
+func TestValidatePropertyRange(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d", "e"})
+	dag.Services[0].Properties["replicas"] = 0
+	dag.Services[1].Properties["replicas"] = 3
+	dag.Services[2].Properties["replicas"] = 2.5
+	dag.Services[3].Properties["replicas"] = "many"
+
+	errs := dag.ValidatePropertyRange("replicas", 1, 5)
+	assert.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "'a'")
+	assert.Contains(t, errs[1].Error(), "'d'")
+}
+
+func TestValidateEntityBudget(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"}, [2]string{"a", "b"}, [2]string{"b", "c"})
+
+	assert.Empty(t, dag.ValidateEntityBudget(3, 2))
+}
+
+func TestValidateEntityBudgetFail(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"}, [2]string{"a", "b"}, [2]string{"b", "c"})
+
+	assert.Len(t, dag.ValidateEntityBudget(2, 2), 1)
+	assert.Len(t, dag.ValidateEntityBudget(2, 1), 2)
+}
+
+func TestValidateDescriptionLength(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	assert.Empty(t, dag.ValidateDescriptionLength(64))
+
+	dag.Relationships[1].Description = strings.Repeat("x", 65)
+
+	errs := dag.ValidateDescriptionLength(64)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'Event Hubs to Event Logger Link'")
+}
+
+func TestValidateTypeOrdering(t *testing.T) {
+	dag := buildConfig([]string{"lb", "web", "db"},
+		[2]string{"lb", "web"},
+		[2]string{"web", "db"},
+		[2]string{"db", "web"},
+	)
+	dag.Services[0].Type = "lb"
+	dag.Services[1].Type = "web"
+	dag.Services[2].Type = "db"
+
+	allowed := map[string][]string{
+		"lb":  {"web"},
+		"web": {"db"},
+	}
+
+	errs := dag.ValidateTypeOrdering(allowed)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'db to web'")
+}
+
+func TestValidateUniqueRelationshipTriples(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	assert.Empty(t, dag.ValidateUniqueRelationshipTriples())
+
+	duplicate := dag.Relationships[0]
+	duplicate.ID = "Generator to Event Hubs Link Copy"
+	dag.Relationships = append(dag.Relationships, duplicate)
+
+	errs := dag.ValidateUniqueRelationshipTriples()
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'Generator to Event Hubs Link Copy'")
+}
+
+func TestExpectType(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	assert.NoError(t, dag.ExpectType("Azure Event Hub", "EventHub"))
+	assert.NoError(t, dag.ExpectType("Azure Event Hub", "eventhub"))
+	assert.Error(t, dag.ExpectType("Azure Event Hub", "EventLogger"))
+	assert.Error(t, dag.ExpectType("Missing", "EventHub"))
+}
+
+func TestValidatePropertyEnum(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"})
+	dag.Services[0].Properties["tier"] = "gold"
+	dag.Services[1].Properties["tier"] = "platinum"
+	dag.Services[2].Properties["tier"] = 3
+
+	errs := dag.ValidatePropertyEnum("tier", []string{"bronze", "silver", "gold"})
+	assert.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "'b'")
+	assert.Contains(t, errs[1].Error(), "'c'")
+}
+
+func TestValidateReferences(t *testing.T) {
+	dag := new(constellation.Config)
+	err := dag.LoadFile("testdata/valid.yaml")
+	assert.NoError(t, err)
+
+	assert.Empty(t, dag.ValidateReferences())
+
+	dag.Relationships[0].From = "Missing Generator"
+	dag.Relationships[1].To = "Missing Logger"
+
+	errs := dag.ValidateReferences()
+	assert.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "'Generator to Event Hubs Link'")
+	assert.Contains(t, errs[0].Error(), "'Missing Generator'")
+	assert.Contains(t, errs[1].Error(), "'Event Hubs to Event Logger Link'")
+	assert.Contains(t, errs[1].Error(), "'Missing Logger'")
+}
+
+func TestValidateReferencesMiscased(t *testing.T) {
+	dag := buildConfig([]string{"api", "db"}, [2]string{"API", "db"})
+	dag.CaseMatching = constellation.AnyCase
+
+	errs := dag.ValidateReferences()
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "references From service 'api' miscased as 'API'")
+	}
+
+	dag.CaseMatching = constellation.ExactCase
+	errs = dag.ValidateReferences()
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "references missing From service 'API'")
+	}
+}
+
+func TestValidateReferencesSelfLoop(t *testing.T) {
+	dag := buildConfig([]string{"a"}, [2]string{"a", "a"})
+
+	errs := dag.ValidateReferences()
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "itself")
+}
+
+func TestValidateIsTree(t *testing.T) {
+	dag := buildConfig([]string{"root", "a", "b", "c"},
+		[2]string{"root", "a"},
+		[2]string{"root", "b"},
+		[2]string{"a", "c"},
+	)
+
+	assert.NoError(t, dag.ValidateIsTree())
+}
+
+func TestValidateIsTreeJoin(t *testing.T) {
+	dag := buildConfig([]string{"root", "a", "b", "join"},
+		[2]string{"root", "a"},
+		[2]string{"root", "b"},
+		[2]string{"a", "join"},
+		[2]string{"b", "join"},
+	)
+
+	err := dag.ValidateIsTree()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "'join'")
+}
+
+func TestValidateIsTreeDisconnected(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c", "d"},
+		[2]string{"a", "b"},
+		[2]string{"c", "d"},
+	)
+
+	assert.Error(t, dag.ValidateIsTree())
+}
+
+func TestValidateIntraGroupEdges(t *testing.T) {
+	dag := buildConfig([]string{"payments.api", "payments.db", "search.api"},
+		[2]string{"payments.api", "payments.db"},
+		[2]string{"search.api", "payments.db"},
+	)
+
+	errs := dag.ValidateIntraGroupEdges(".")
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'search.api to payments.db'")
+	assert.Contains(t, errs[0].Error(), "'search' to group 'payments'")
+
+	dag.Relationships = dag.Relationships[:1]
+	assert.Empty(t, dag.ValidateIntraGroupEdges("."))
+}
+
+func TestValidateNoPlaceholders(t *testing.T) {
+	dag := buildConfig([]string{"api", "TODO"},
+		[2]string{"api", "TODO"},
+	)
+	dag.Relationships[0].ID = "CHANGEME"
+	dag.Relationships[0].Description = "TODO"
+
+	errs := dag.ValidateNoPlaceholders([]string{"TODO", "CHANGEME"})
+	if assert.Len(t, errs, 2) {
+		assert.Contains(t, errs[0].Error(), "Service 'TODO'")
+		assert.Contains(t, errs[1].Error(), "Relationship 'CHANGEME' has a placeholder ID")
+	}
+
+	assert.Empty(t, dag.ValidateNoPlaceholders([]string{"FIXME"}))
+}
+
+func TestValidateGUIDVersion(t *testing.T) {
+	dag := buildConfig([]string{"a", "b"}, [2]string{"a", "b"})
+	dag.ID = "d6e4a5e9-696a-4626-ba7a-534d6ff450a5"
+	dag.Relationships[0].ID = "c232ab00-9414-11ec-b3c8-9e6bdeced846"
+
+	errs := dag.ValidateGUIDVersion(4)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'c232ab00-9414-11ec-b3c8-9e6bdeced846' is a version 1 GUID")
+
+	dag.Relationships[0].ID = "a to b"
+	assert.Empty(t, dag.ValidateGUIDVersion(4))
+
+	dag.ID = "test"
+	assert.Len(t, dag.ValidateGUIDVersion(4), 1)
+}
+
+func TestValidateRootType(t *testing.T) {
+	dag := buildConfig([]string{"gateway", "admin", "api", "db"},
+		[2]string{"gateway", "api"},
+		[2]string{"admin", "api"},
+		[2]string{"api", "db"},
+	)
+	dag.Services[0].Type = "ingress"
+
+	errs := dag.ValidateRootType("ingress")
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'admin' has type 'Test'")
+
+	dag.Services[1].Type = "ingress"
+	assert.Empty(t, dag.ValidateRootType("ingress"))
+}
+
+func TestValidatePartitionable(t *testing.T) {
+	dag := buildConfig([]string{"a", "b", "c"},
+		[2]string{"a", "b"},
+		[2]string{"b", "c"},
+	)
+
+	assert.Empty(t, dag.ValidatePartitionable(3, nil))
+	assert.Empty(t, dag.ValidatePartitionable(2, []string{"a to b"}))
+
+	errs := dag.ValidatePartitionable(2, []string{"a to b", "b to c"})
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "the most possible is 1")
+
+	errs = dag.ValidatePartitionable(3, []string{"missing"})
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'missing' not found")
+
+	assert.Len(t, dag.ValidatePartitionable(0, nil), 1)
+}
+
+func TestValidateLeafTypes(t *testing.T) {
+	dag := buildConfig([]string{"source", "store", "logger"},
+		[2]string{"source", "store"},
+		[2]string{"source", "logger"},
+	)
+	dag.Services[1].Type = "sink"
+	dag.Services[2].Type = "worker"
+
+	errs := dag.ValidateLeafTypes([]string{"sink"})
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "'logger' has type 'worker'")
+
+	assert.Empty(t, dag.ValidateLeafTypes([]string{"sink", "worker"}))
+}
+
+func TestValidateGUIDs(t *testing.T) {
+	dag := new(constellation.Config)
+	_ = dag.LoadFile("testdata/valid.yaml")
+	assert.Empty(t, dag.ValidateGUIDs())
+
+	dag = buildConfig([]string{"a", "", "d6e4a5e9-696a-4626-ba7a-534d6ff450a5"},
+		[2]string{"a", "d6e4a5e9-696a-4626"},
+	)
+	dag.ID = "d6e4a5e9-696a-4626-ba7a-534d6ff450a5"
+
+	errs := dag.ValidateGUIDs()
+	if assert.Len(t, errs, 2) {
+		assert.Contains(t, errs[0].Error(), "Service 1 '' has an empty ID")
+		assert.Contains(t, errs[1].Error(), "has a malformed To 'd6e4a5e9-696a-4626'")
+	}
+
+	dag.ID = "test"
+	assert.Len(t, dag.ValidateGUIDs(), 3)
+
+	dag = buildConfig([]string{"a-b", "cafe-db", "dead-beef", "3fa85f64"})
+	dag.ID = "d6e4a5e9-696a-4626-ba7a-534d6ff450a5"
+
+	errs = dag.ValidateGUIDs()
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "Service 3 '3fa85f64' has a malformed ID '3fa85f64'")
+	}
+}
+
+func TestValidateCaseInsensitiveNameUniqueness(t *testing.T) {
+	dag := buildConfig([]string{"Api", "db", "api", "Db2"})
+
+	errs := dag.ValidateCaseInsensitiveNameUniqueness()
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "'Api' and 'api'")
+	}
+
+	assert.Empty(t, buildConfig([]string{"api", "api", "db"}).ValidateCaseInsensitiveNameUniqueness())
+}
@@ -10,7 +10,14 @@ package guid
 //
 ////////////////////////////////////////////////////////////
 
-import "strings"
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
 
 // If true, tolerate Find requests where the case is incorrect.
 // e.g. if asked for Name="abc", then okay to return the object
@@ -31,6 +38,45 @@ type GUID string
 // Empty -- equivalent to an uninitialized GUID.
 const Empty = GUID("")
 
+// pattern matches the 8-4-4-4-12 hex format of a GUID, in any case.
+var pattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// New -- a new random (version 4) GUID.
+func New() GUID {
+	return NewFrom(rand.Reader)
+}
+
+// NewFrom -- a new version 4 GUID using random bytes from r, e.g. a seeded math/rand source for reproducible IDs.
+func NewFrom(r io.Reader) GUID {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(r, b); err != nil {
+		panic(err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return GUID(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]))
+}
+
+// IsValid -- true if the GUID is in the canonical 8-4-4-4-12 hex format.
+func (LHS GUID) IsValid() bool {
+	return pattern.MatchString(string(LHS))
+}
+
+// Version -- the version digit of a GUID in the 8-4-4-4-12 hex format, false if it isn't in that format.
+func (LHS GUID) Version() (int, bool) {
+	if !LHS.IsValid() {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(string(LHS[14]), 16, 0)
+	if err != nil {
+		return 0, false
+	}
+	return int(version), true
+}
+
 // IsEmpty - true if GUID represents empty value.
 func (LHS GUID) IsEmpty() bool {
 	return Empty.Equals(LHS)
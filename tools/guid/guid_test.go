@@ -1,6 +1,7 @@
 package guid_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/microsoft/abstrakt/tools/guid"
@@ -66,3 +67,47 @@ func TestGUID_Equals(t *testing.T) {
 		})
 	}
 }
+
+func TestGUID_New(t *testing.T) {
+	id := guid.New()
+
+	assert.Len(t, string(id), 36)
+	assert.Equal(t, "4", string(id[14]), "GUID should be version 4")
+	assert.False(t, id.Equals(guid.New()), "GUIDs should be unique")
+}
+
+func TestGUID_NewFrom(t *testing.T) {
+	id := guid.NewFrom(bytes.NewReader(make([]byte, 16)))
+	assert.Equal(t, guid.GUID("00000000-0000-4000-8000-000000000000"), id)
+
+	assert.Panics(t, func() { guid.NewFrom(bytes.NewReader(nil)) })
+}
+
+func TestGUID_Version(t *testing.T) {
+	version, ok := guid.GUID("d6e4a5e9-696a-4626-ba7a-534d6ff450a5").Version()
+	assert.True(t, ok)
+	assert.Equal(t, 4, version)
+
+	version, ok = guid.GUID("C232AB00-9414-11EC-B3C8-9E6BDECED846").Version()
+	assert.True(t, ok)
+	assert.Equal(t, 1, version)
+
+	version, ok = guid.New().Version()
+	assert.True(t, ok)
+	assert.Equal(t, 4, version)
+
+	for _, i := range []guid.GUID{guid.Empty, "some junk", "d6e4a5e9696a4626ba7a534d6ff450a5", "g6e4a5e9-696a-4626-ba7a-534d6ff450a5"} {
+		_, ok = i.Version()
+		assert.False(t, ok, "%q should not have a version", i)
+	}
+}
+
+func TestGUID_IsValid(t *testing.T) {
+	assert.True(t, guid.GUID("d6e4a5e9-696a-4626-ba7a-534d6ff450a5").IsValid())
+	assert.True(t, guid.GUID("D6E4A5E9-696A-4626-BA7A-534D6FF450A5").IsValid())
+	assert.True(t, guid.New().IsValid())
+
+	assert.False(t, guid.Empty.IsValid())
+	assert.False(t, guid.GUID("d6e4a5e9-696a-4626-ba7a").IsValid())
+	assert.False(t, guid.GUID("{d6e4a5e9-696a-4626-ba7a-534d6ff450a5}").IsValid())
+}